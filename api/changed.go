@@ -1,9 +1,11 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"path/filepath"
+	"strings"
 )
 
 var ErrChangedFileIsNotDirectory = errors.New("changed file is not a directory")
@@ -33,3 +35,34 @@ func UnmarshalChangedFileJson(j []byte) ([]string, error) {
 func isDir(path string) bool {
 	return path == "." || filepath.Ext(path) == ""
 }
+
+// UnmarshalChangedFiles parses a list of changed file paths.
+//
+// The list may be a JSON array, newline separated, or NUL separated such as the output of `git diff -z --name-only`.
+// Empty entries are ignored.
+func UnmarshalChangedFiles(b []byte) ([]string, error) {
+	var rawPaths []string
+
+	trimmed := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		err := json.Unmarshal(trimmed, &rawPaths)
+		if err != nil {
+			return nil, err
+		}
+	case bytes.IndexByte(b, 0) >= 0:
+		rawPaths = strings.Split(string(b), "\x00")
+	default:
+		rawPaths = strings.Split(string(b), "\n")
+	}
+
+	paths := make([]string, 0, len(rawPaths))
+	for _, path := range rawPaths {
+		path = strings.TrimRight(path, "\r")
+		if strings.TrimSpace(path) == "" {
+			continue
+		}
+		paths = append(paths, filepath.Clean(filepath.FromSlash(path)))
+	}
+	return paths, nil
+}
@@ -31,3 +31,39 @@ func TestUnmarshalChangedFileJson_RejectChangedFilesIsFiles(t *testing.T) {
 	assert.ErrorContains(t, err, "foo/main.tf: "+ErrChangedFileIsNotDirectory.Error())
 	assert.Nil(t, actualPaths, "actualPaths should be nil")
 }
+
+func TestUnmarshalChangedFiles(t *testing.T) {
+	expectedPaths := []string{
+		filepath.Join("README.md"),
+		filepath.Join("foo", "main.tf"),
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "JSON", input: `["README.md", "foo/main.tf"]`},
+		{name: "Newline separated", input: "README.md\nfoo/main.tf\n"},
+		{name: "CRLF separated", input: "README.md\r\nfoo/main.tf\r\n"},
+		{name: "NUL separated", input: "README.md\x00foo/main.tf\x00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actualPaths, err := UnmarshalChangedFiles([]byte(tt.input))
+			assert.NoError(t, err)
+			assert.Equal(t, expectedPaths, actualPaths)
+		})
+	}
+}
+
+func TestUnmarshalChangedFiles_Empty(t *testing.T) {
+	actualPaths, err := UnmarshalChangedFiles([]byte("\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, actualPaths)
+}
+
+func TestUnmarshalChangedFiles_InvalidJson(t *testing.T) {
+	_, err := UnmarshalChangedFiles([]byte(`["README.md"`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidContext = errors.New("invalid context")
+
+// contextTypes are the types a ContextKeySchema may declare, named as in JSON Schema.
+var contextTypes = []string{"string", "number", "integer", "boolean", "array", "object"}
+
+// ContextKeySchema declares the expected type of a context key.
+type ContextKeySchema struct {
+	// Type is one of string, number, integer, boolean, array or object.
+	Type string `yaml:"type"`
+	// Items is the type of each item of an array.
+	Items string `yaml:"items,omitempty"`
+	// Required rejects a configuration without the key, after defaults are merged.
+	Required bool `yaml:"required,omitempty"`
+}
+
+// validate returns an error for every invalid field of the schema of key.
+func (s ContextKeySchema) validate(key string) []*ValidationError {
+	var errs []*ValidationError
+	if !isContextType(s.Type) {
+		errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.contextSchema.%s.type %q", key, s.Type), "spec", "contextSchema", key, "type"))
+	}
+	if s.Items != "" && (s.Type != "array" || !isContextType(s.Items)) {
+		errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.contextSchema.%s.items %q: must be a type of the items of an array", key, s.Items), "spec", "contextSchema", key, "items"))
+	}
+	return errs
+}
+
+func isContextType(t string) bool {
+	for _, contextType := range contextTypes {
+		if t == contextType {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckContext returns an error for every configuration with a context key of the wrong type, or without a
+// required key. Keys not declared in schema may have any value.
+func CheckContext(items []ConfigurationItem, schema map[string]ContextKeySchema) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, item := range items {
+		for _, key := range keys {
+			s := schema[key]
+			value, ok := item.Context[key]
+			if !ok {
+				if s.Required {
+					errs = append(errs, fmt.Errorf("%s: %w: context.%s is required", item.Path, ErrInvalidContext, key))
+				}
+				continue
+			}
+			if !hasContextType(value, s.Type) {
+				errs = append(errs, fmt.Errorf("%s: %w: context.%s: expected %s, got %s", item.Path, ErrInvalidContext, key, s.Type, contextType(value)))
+				continue
+			}
+			if s.Items == "" {
+				continue
+			}
+			for i, v := range value.([]any) {
+				if !hasContextType(v, s.Items) {
+					errs = append(errs, fmt.Errorf("%s: %w: context.%s[%d]: expected %s, got %s", item.Path, ErrInvalidContext, key, i, s.Items, contextType(v)))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hasContextType reports whether a decoded YAML value is of type t.
+func hasContextType(value any, t string) bool {
+	actual := contextType(value)
+	return actual == t || t == "number" && actual == "integer"
+}
+
+// contextType returns the type of a decoded YAML value, named as in JSON Schema.
+func contextType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
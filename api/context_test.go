@@ -0,0 +1,84 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckContext(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    regions: [ap-southeast-2, us-east-1]
+    replicas: 3
+    ratio: 0.5
+    runner:
+      size: large
+`
+	cfg, err := NewStrict().Unmarshal([]byte(yamlDoc))
+	require.NoError(t, err)
+	cfg.Path = "compute/pantalon.yaml"
+
+	items, err := MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+
+	schema := map[string]ContextKeySchema{
+		"regions":  {Type: "array", Items: "string", Required: true},
+		"replicas": {Type: "number"},
+		"ratio":    {Type: "number"},
+		"runner":   {Type: "object"},
+		"optional": {Type: "string"},
+	}
+	assert.NoError(t, CheckContext(items, schema))
+}
+
+func TestCheckContext_Errors(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "a", Path: "a/pantalon.yaml", Context: map[string]any{"regions": "ap-southeast-2"}},
+		{Name: "b", Path: "b/pantalon.yaml", Context: map[string]any{"regions": []any{"ap-southeast-2", uint64(1)}, "replicas": 1.5}},
+		{Name: "c", Path: "c/pantalon.yaml"},
+	}
+	schema := map[string]ContextKeySchema{
+		"regions":  {Type: "array", Items: "string", Required: true},
+		"replicas": {Type: "integer"},
+	}
+
+	err := CheckContext(items, schema)
+	assert.ErrorIs(t, err, ErrInvalidContext)
+	assert.EqualError(t, err, `a/pantalon.yaml: invalid context: context.regions: expected array, got string
+b/pantalon.yaml: invalid context: context.regions[1]: expected string, got integer
+b/pantalon.yaml: invalid context: context.replicas: expected integer, got number
+c/pantalon.yaml: invalid context: context.regions is required`)
+}
+
+func TestMarshalItems_TypedContextJSON(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    enabled: true
+    regions: [ap-southeast-2, us-east-1]
+    runner:
+      size: large
+      replicas: 2
+`
+	cfg, err := New().Unmarshal([]byte(yamlDoc))
+	require.NoError(t, err)
+
+	items, err := MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+
+	b, err := yaml.MarshalWithOptions(items[0].Context, yaml.JSON())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"enabled": true, "regions": ["ap-southeast-2", "us-east-1"], "runner": {"replicas": 2, "size": "large"}}`, string(b))
+}
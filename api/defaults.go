@@ -0,0 +1,84 @@
+package api
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+const ContextDefaultsKind = "ContextDefaults"
+
+// contextDefaultsVersions are the apiVersions of a pantalon.defaults.yaml, which change independently of those of
+// pantalon.yaml files.
+var contextDefaultsVersions = []string{V1Beta1}
+
+// ContextDefaults is a pantalon.defaults.yaml, holding the context inherited by every configuration within its
+// directory.
+type ContextDefaults struct {
+	ApiVersion string              `yaml:"apiVersion"`
+	Kind       string              `yaml:"kind"`
+	Spec       ContextDefaultsSpec `yaml:"spec,omitempty"`
+	Path       string              `yaml:"-"`
+}
+
+type ContextDefaultsSpec struct {
+	Context map[string]any `yaml:"context,omitempty"`
+}
+
+// UnmarshalContextDefaults decodes a pantalon.defaults.yaml document, rejecting unknown fields.
+func UnmarshalContextDefaults(yamlDoc []byte) (ContextDefaults, error) {
+	defaults := ContextDefaults{}
+
+	file, err := parser.ParseBytes(yamlDoc, 0)
+	if err != nil {
+		return defaults, decodeError(err)
+	}
+
+	err = yaml.Unmarshal(yamlDoc, &defaults)
+	if err != nil {
+		return defaults, decodeError(err)
+	}
+
+	errs := strictCheck(file, reflect.TypeOf(defaults))
+	if !slices.Contains(contextDefaultsVersions, defaults.ApiVersion) {
+		errs = append(errs, newValidationError("invalid version", "apiVersion"))
+	}
+	if defaults.Kind != ContextDefaultsKind {
+		errs = append(errs, newValidationError("invalid kind", "kind"))
+	}
+	if len(errs) > 0 {
+		locate(file, errs)
+		return defaults, joinValidationErrors(errs)
+	}
+	return defaults, nil
+}
+
+// WithDefaults returns cfg with the context of each of defaults, outermost first, merged into its context. A later
+// file takes precedence, and the context of cfg takes precedence over all of them.
+//
+// ContextSources records the path of the file each inherited key came from.
+func (cfg TerraformConfiguration) WithDefaults(defaults []ContextDefaults) TerraformConfiguration {
+	context := make(map[string]any)
+	sources := make(map[string]string)
+	for _, d := range defaults {
+		for k, v := range d.Spec.Context {
+			context[k] = v
+			sources[k] = d.Path
+		}
+	}
+	if len(context) == 0 {
+		return cfg
+	}
+
+	for k, v := range cfg.Context {
+		context[k] = v
+		delete(sources, k)
+	}
+	cfg.Context = context
+	if len(sources) > 0 {
+		cfg.ContextSources = sources
+	}
+	return cfg
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalContextDefaults(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: ContextDefaults
+spec:
+  context:
+    gcp-project: "shared"
+`
+	defaults, err := UnmarshalContextDefaults([]byte(yamlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"gcp-project": "shared"}, defaults.Spec.Context)
+}
+
+func TestUnmarshalContextDefaults_AllErrorsWithPositions(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+context:
+  gcp-project: shared
+`
+	_, err := UnmarshalContextDefaults([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 3)
+	assert.Equal(t, "unknown field context, did you mean spec.context?", errs[0].Message)
+	assert.Equal(t, 4, errs[0].Line)
+	assert.Equal(t, "invalid version", errs[1].Message)
+	assert.Equal(t, "invalid kind", errs[2].Message)
+}
+
+func TestWithDefaults(t *testing.T) {
+	cfg := TerraformConfiguration{Context: map[string]any{"env": "prod"}}
+	defaults := []ContextDefaults{
+		{Path: "pantalon.defaults.yaml", Spec: ContextDefaultsSpec{Context: map[string]any{"env": "default", "region": "eu", "project": "shared"}}},
+		{Path: "envs/pantalon.defaults.yaml", Spec: ContextDefaultsSpec{Context: map[string]any{"region": "us"}}},
+	}
+
+	result := cfg.WithDefaults(defaults)
+
+	assert.Equal(t, map[string]any{"env": "prod", "region": "us", "project": "shared"}, result.Context)
+	assert.Equal(t, map[string]string{"region": "envs/pantalon.defaults.yaml", "project": "pantalon.defaults.yaml"}, result.ContextSources)
+	assert.Equal(t, map[string]any{"env": "prod"}, cfg.Context)
+
+	assert.Equal(t, cfg, cfg.WithDefaults(nil))
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrUnknownDependency   = errors.New("unknown dependency")
+	ErrAmbiguousDependency = errors.New("ambiguous dependency")
+	ErrDependencyCycle     = errors.New("dependency cycle")
+)
+
+// dependencyGraph indexes items by position, with edges from each item to the items it depends on.
+type dependencyGraph struct {
+	items     []ConfigurationItem
+	dependsOn [][]int
+}
+
+// newDependencyGraph resolves every spec.dependsOn reference to an item.
+// Every reference must match exactly one item by name.
+func newDependencyGraph(items []ConfigurationItem) (dependencyGraph, error) {
+	byName := make(map[string][]int, len(items))
+	for i, item := range items {
+		byName[item.Name] = append(byName[item.Name], i)
+	}
+
+	g := dependencyGraph{
+		items:     items,
+		dependsOn: make([][]int, len(items)),
+	}
+
+	var errs []error
+	for i, item := range items {
+		for _, dep := range item.DependsOn {
+			matches := byName[dep]
+			switch len(matches) {
+			case 0:
+				errs = append(errs, fmt.Errorf("%s: %w %q", item.Path, ErrUnknownDependency, dep))
+			case 1:
+				g.dependsOn[i] = append(g.dependsOn[i], matches[0])
+			default:
+				errs = append(errs, fmt.Errorf("%s: %w %q matches %d configurations", item.Path, ErrAmbiguousDependency, dep, len(matches)))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return dependencyGraph{}, errors.Join(errs...)
+	}
+	return g, nil
+}
+
+// SortByDependencies returns items in topological order, so every item appears after the items it depends on.
+//
+// Items without a dependency relationship keep their original relative order. An error is returned if a
+// dependency refers to an unknown or ambiguous name, or if the dependencies contain a cycle.
+func SortByDependencies(items []ConfigurationItem) ([]ConfigurationItem, error) {
+	g, err := newDependencyGraph(items)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]ConfigurationItem, 0, len(items))
+	for _, i := range order {
+		sorted = append(sorted, items[i])
+	}
+	return sorted, nil
+}
+
+// topologicalOrder returns item indices using Kahn's algorithm, always choosing the lowest ready index first.
+func (g dependencyGraph) topologicalOrder() ([]int, error) {
+	pending := make([]int, len(g.items))
+	dependents := make([][]int, len(g.items))
+	for i, deps := range g.dependsOn {
+		pending[i] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	var ready []int
+	for i, n := range pending {
+		if n == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(g.items))
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		order = append(order, i)
+
+		for _, dependent := range dependents[i] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				pos := sort.SearchInts(ready, dependent)
+				ready = append(ready, 0)
+				copy(ready[pos+1:], ready[pos:])
+				ready[pos] = dependent
+			}
+		}
+	}
+
+	if len(order) < len(g.items) {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, g.findCycle(pending))
+	}
+	return order, nil
+}
+
+// findCycle describes a cycle among items which could not be ordered, e.g. "a -> b -> a".
+func (g dependencyGraph) findCycle(pending []int) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.items))
+	var stack []int
+	var cycle []int
+
+	var visit func(i int) bool
+	visit = func(i int) bool {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, dep := range g.dependsOn[i] {
+			switch state[dep] {
+			case visiting:
+				for j, s := range stack {
+					if s == dep {
+						cycle = append(append(cycle, stack[j:]...), dep)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(dep) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return false
+	}
+
+	for i := range g.items {
+		if pending[i] > 0 && state[i] == unvisited && visit(i) {
+			break
+		}
+	}
+
+	names := make([]string, 0, len(cycle))
+	for _, i := range cycle {
+		names = append(names, g.items[i].Name)
+	}
+	return strings.Join(names, " -> ")
+}
+
+// GroupWaves groups items into ordered waves, where every item in wave N only depends on items in earlier waves.
+//
+// Each item is placed in the earliest wave possible. Within a wave, items keep their original relative order.
+func GroupWaves(items []ConfigurationItem) ([][]ConfigurationItem, error) {
+	g, err := newDependencyGraph(items)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := g.topologicalOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	wave := make([]int, len(items))
+	count := 0
+	for _, i := range order {
+		for _, dep := range g.dependsOn[i] {
+			wave[i] = max(wave[i], wave[dep]+1)
+		}
+		count = max(count, wave[i]+1)
+	}
+
+	waves := make([][]ConfigurationItem, count)
+	for i, item := range items {
+		waves[wave[i]] = append(waves[wave[i]], item)
+	}
+	return waves, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dependencyNames(items []ConfigurationItem) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestSortByDependencies_NoDependenciesKeepsOrder(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "c"},
+		{Name: "a"},
+		{Name: "b"},
+	}
+
+	result, err := SortByDependencies(items)
+	require.NoError(t, err)
+	assert.Equal(t, items, result)
+}
+
+func TestSortByDependencies_DependencyBeforeDependent(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "compute-prod", DependsOn: []string{"network-prod"}},
+		{Name: "data-prod", DependsOn: []string{"network-prod", "compute-prod"}},
+		{Name: "network-prod"},
+		{Name: "dns"},
+	}
+
+	result, err := SortByDependencies(items)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"network-prod", "compute-prod", "data-prod", "dns"}, dependencyNames(result))
+}
+
+func TestSortByDependencies_UnknownDependency(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "compute-prod", Path: "compute/pantalon.yaml", DependsOn: []string{"network-prod"}},
+	}
+
+	_, err := SortByDependencies(items)
+	assert.ErrorIs(t, err, ErrUnknownDependency)
+	assert.EqualError(t, err, `compute/pantalon.yaml: unknown dependency "network-prod"`)
+}
+
+func TestSortByDependencies_AmbiguousDependency(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "compute-prod", Path: "compute/pantalon.yaml", DependsOn: []string{"network-prod"}},
+		{Name: "network-prod", Path: "network/a/pantalon.yaml"},
+		{Name: "network-prod", Path: "network/b/pantalon.yaml"},
+	}
+
+	_, err := SortByDependencies(items)
+	assert.ErrorIs(t, err, ErrAmbiguousDependency)
+}
+
+func TestSortByDependencies_Cycle(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "dns"},
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"c"}},
+		{Name: "c", DependsOn: []string{"a"}},
+	}
+
+	_, err := SortByDependencies(items)
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+	assert.EqualError(t, err, "dependency cycle: a -> b -> c -> a")
+}
+
+func TestGroupWaves(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "compute-prod", DependsOn: []string{"network-prod"}},
+		{Name: "data-prod", DependsOn: []string{"network-prod", "compute-prod"}},
+		{Name: "network-prod"},
+		{Name: "dns"},
+		{Name: "iam-prod", DependsOn: []string{"dns"}},
+	}
+
+	waves, err := GroupWaves(items)
+	require.NoError(t, err)
+	require.Len(t, waves, 3)
+	assert.Equal(t, []string{"network-prod", "dns"}, dependencyNames(waves[0]))
+	assert.Equal(t, []string{"compute-prod", "iam-prod"}, dependencyNames(waves[1]))
+	assert.Equal(t, []string{"data-prod"}, dependencyNames(waves[2]))
+}
+
+func TestGroupWaves_NoItems(t *testing.T) {
+	waves, err := GroupWaves([]ConfigurationItem{})
+	require.NoError(t, err)
+	assert.Empty(t, waves)
+}
+
+func TestGroupWaves_Cycle(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+
+	_, err := GroupWaves(items)
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+}
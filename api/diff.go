@@ -0,0 +1,128 @@
+package api
+
+import (
+	"reflect"
+)
+
+// InventoryDiff describes how the configurations in a repository changed between two trees.
+type InventoryDiff struct {
+	Added          []ConfigurationItem `yaml:"added"`
+	Removed        []ConfigurationItem `yaml:"removed"`
+	Renamed        []ItemChange        `yaml:"renamed"`
+	Moved          []ItemChange        `yaml:"moved"`
+	ContextChanged []ItemChange        `yaml:"contextChanged"`
+}
+
+// ItemChange is a configuration as it was before and after a change.
+type ItemChange struct {
+	From ConfigurationItem `yaml:"from"`
+	To   ConfigurationItem `yaml:"to"`
+}
+
+// DiffInventories compares two lists of configurations.
+//
+// Configurations in the same directory are the same configuration, which was renamed if its name differs.
+// Otherwise configurations with the same name are the same configuration, which was moved to a new
+// directory. Any remaining configurations were added or removed. The context of every matched
+// configuration is compared.
+//
+// A directory may contain several configurations, such as the items of a matrix, which are matched by name first.
+func DiffInventories(from []ConfigurationItem, to []ConfigurationItem) InventoryDiff {
+	diff := InventoryDiff{
+		Added:          make([]ConfigurationItem, 0),
+		Removed:        make([]ConfigurationItem, 0),
+		Renamed:        make([]ItemChange, 0),
+		Moved:          make([]ItemChange, 0),
+		ContextChanged: make([]ItemChange, 0),
+	}
+
+	sameDir, matched := matchDirs(from, to)
+	var unmatched []ConfigurationItem
+	var changes []ItemChange
+
+	for f, item := range from {
+		i := sameDir[f]
+		if i < 0 {
+			unmatched = append(unmatched, item)
+			continue
+		}
+
+		change := ItemChange{From: item, To: to[i]}
+		if item.Name != to[i].Name {
+			diff.Renamed = append(diff.Renamed, change)
+		}
+		changes = append(changes, change)
+	}
+
+	toByName := make(map[string][]int, len(to))
+	for i, item := range to {
+		if !matched[i] {
+			toByName[item.Name] = append(toByName[item.Name], i)
+		}
+	}
+
+	for _, item := range unmatched {
+		candidates := toByName[item.Name]
+		if len(candidates) == 0 {
+			diff.Removed = append(diff.Removed, item)
+			continue
+		}
+		i := candidates[0]
+		toByName[item.Name] = candidates[1:]
+		matched[i] = true
+
+		change := ItemChange{From: item, To: to[i]}
+		diff.Moved = append(diff.Moved, change)
+		changes = append(changes, change)
+	}
+
+	for i, item := range to {
+		if !matched[i] {
+			diff.Added = append(diff.Added, item)
+		}
+	}
+
+	for _, change := range changes {
+		if !reflect.DeepEqual(change.From.Context, change.To.Context) {
+			diff.ContextChanged = append(diff.ContextChanged, change)
+		}
+	}
+
+	return diff
+}
+
+// matchDirs returns the index of the item of to in the same directory as each item of from, or -1, and whether each
+// item of to was matched. A directory may contain several items, such as those of a matrix, so items with the same
+// name are matched first, then the rest in order.
+func matchDirs(from []ConfigurationItem, to []ConfigurationItem) ([]int, []bool) {
+	toByDir := make(map[string][]int, len(to))
+	for i, item := range to {
+		toByDir[item.Dir] = append(toByDir[item.Dir], i)
+	}
+
+	result := make([]int, len(from))
+	matched := make([]bool, len(to))
+	for f, item := range from {
+		result[f] = -1
+		for _, i := range toByDir[item.Dir] {
+			if !matched[i] && to[i].Name == item.Name {
+				result[f] = i
+				matched[i] = true
+				break
+			}
+		}
+	}
+	for f, item := range from {
+		if result[f] >= 0 {
+			continue
+		}
+		for _, i := range toByDir[item.Dir] {
+			if !matched[i] {
+				result[f] = i
+				matched[i] = true
+				break
+			}
+		}
+	}
+	return result, matched
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffInventories(t *testing.T) {
+	from := []ConfigurationItem{
+		{Name: "unchanged", Dir: "unchanged", Context: map[string]any{"env": "dev"}},
+		{Name: "old-name", Dir: "renamed"},
+		{Name: "moved", Dir: "old-dir"},
+		{Name: "context", Dir: "context", Context: map[string]any{"env": "dev"}},
+		{Name: "removed", Dir: "removed"},
+	}
+	to := []ConfigurationItem{
+		{Name: "added", Dir: "added"},
+		{Name: "unchanged", Dir: "unchanged", Context: map[string]any{"env": "dev"}},
+		{Name: "new-name", Dir: "renamed"},
+		{Name: "moved", Dir: "new-dir", Context: map[string]any{"env": "prod"}},
+		{Name: "context", Dir: "context", Context: map[string]any{"env": "prod"}},
+	}
+
+	diff := DiffInventories(from, to)
+
+	assert.Equal(t, InventoryDiff{
+		Added:   []ConfigurationItem{to[0]},
+		Removed: []ConfigurationItem{from[4]},
+		Renamed: []ItemChange{{From: from[1], To: to[2]}},
+		Moved:   []ItemChange{{From: from[2], To: to[3]}},
+		ContextChanged: []ItemChange{
+			{From: from[3], To: to[4]},
+			{From: from[2], To: to[3]},
+		},
+	}, diff)
+}
+
+func TestDiffInventories_SameDir(t *testing.T) {
+	from := []ConfigurationItem{
+		{Name: "compute-old", Dir: "compute"},
+		{Name: "compute-us", Dir: "compute"},
+		{Name: "compute-eu", Dir: "compute"},
+	}
+	to := []ConfigurationItem{
+		{Name: "compute-eu", Dir: "compute"},
+		{Name: "compute-us", Dir: "compute"},
+		{Name: "compute-ap", Dir: "compute"},
+	}
+
+	diff := DiffInventories(from, to)
+
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Equal(t, []ItemChange{{From: from[0], To: to[2]}}, diff.Renamed)
+}
+
+func TestDiffInventories_Empty(t *testing.T) {
+	diff := DiffInventories(nil, nil)
+
+	assert.Equal(t, InventoryDiff{
+		Added:          []ConfigurationItem{},
+		Removed:        []ConfigurationItem{},
+		Renamed:        []ItemChange{},
+		Moved:          []ItemChange{},
+		ContextChanged: []ItemChange{},
+	}, diff)
+}
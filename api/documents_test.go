@@ -0,0 +1,87 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalAll_SingleDocument(t *testing.T) {
+	yamlDoc := `# yaml-language-server: $schema=pantalon.schema.json
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`
+	cfgs, err := NewStrict().UnmarshalAll([]byte(yamlDoc))
+	require.NoError(t, err)
+	require.Len(t, cfgs, 1)
+	assert.Equal(t, "compute-prod", cfgs[0].Metadata.Name)
+	assert.Nil(t, cfgs[0].Document)
+}
+
+func TestUnmarshalAll_MultipleDocuments(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+spec:
+  context:
+    workspace: eu
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-us
+context:
+  workspace: us
+`
+	cfgs, err := NewStrict().UnmarshalAll([]byte(yamlDoc))
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+
+	assert.Equal(t, "compute-eu", cfgs[0].Metadata.Name)
+	assert.Equal(t, 0, *cfgs[0].Document)
+	assert.Equal(t, V1Beta1, cfgs[0].ApiVersion)
+	assert.Equal(t, "compute-us", cfgs[1].Metadata.Name)
+	assert.Equal(t, 1, *cfgs[1].Document)
+	assert.Equal(t, map[string]any{"workspace": "us"}, cfgs[1].Context)
+
+	items, err := MarshalItems(cfgs)
+	require.NoError(t, err)
+	assert.Equal(t, 0, *items[0].Document)
+	assert.Equal(t, 1, *items[1].Document)
+}
+
+func TestUnmarshalAll_ErrorsOfEveryDocument(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: Compute_US
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-ap
+spec:
+  dependson: [network]
+`
+	cfgs, err := NewStrict().UnmarshalAll([]byte(yamlDoc))
+	assert.Nil(t, cfgs)
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "invalid metadata.name", errs[0].Message)
+	assert.Equal(t, 10, errs[0].Line)
+	assert.Equal(t, "unknown field spec.dependson, did you mean spec.dependsOn?", errs[1].Message)
+	assert.Equal(t, 17, errs[1].Line)
+}
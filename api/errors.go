@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+)
+
+// ValidationError is an invalid field in a pantalon.yaml file.
+type ValidationError struct {
+	// Path is the path of the file, if known.
+	Path string
+	// Line and Column are the 1-based position of the field in the file, or 0 if unknown.
+	Line   int
+	Column int
+	// Field is the path of the field within the document, e.g. `metadata.name`.
+	Field   string
+	Message string
+
+	// segments are the keys and indices of Field, used to find its position.
+	segments []any
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Path == "":
+		return e.Message
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Message)
+	default:
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+}
+
+// newValidationError creates an error for the field at segments, each a string key or int index.
+func newValidationError(message string, segments ...any) *ValidationError {
+	var field strings.Builder
+	for _, s := range segments {
+		switch s := s.(type) {
+		case string:
+			if field.Len() > 0 {
+				field.WriteString(".")
+			}
+			field.WriteString(s)
+		case int:
+			fmt.Fprintf(&field, "[%d]", s)
+		}
+	}
+
+	return &ValidationError{Field: field.String(), Message: message, segments: segments}
+}
+
+// joinValidationErrors joins errs with errors.Join.
+func joinValidationErrors(errs []*ValidationError) error {
+	result := make([]error, 0, len(errs))
+	for _, err := range errs {
+		result = append(result, err)
+	}
+	return errors.Join(result...)
+}
+
+// decodeError converts an error from decoding YAML into a ValidationError with its position.
+func decodeError(err error) error {
+	var yamlErr yaml.Error
+	if !errors.As(err, &yamlErr) {
+		return err
+	}
+
+	validationErr := &ValidationError{Message: yamlErr.GetMessage()}
+	if tk := yamlErr.GetToken(); tk != nil && tk.Position != nil {
+		validationErr.Line = tk.Position.Line
+		validationErr.Column = tk.Position.Column
+	}
+	return validationErr
+}
+
+// locate sets the position of each error without one to its field in file. If the field is missing, the position of the
+// key of the nearest parent which exists is used.
+func locate(file *ast.File, errs []*ValidationError) {
+	for _, err := range errs {
+		if err.Line > 0 {
+			continue
+		}
+		node := findNode(file, err.segments)
+		for n := len(err.segments) - 1; node == nil && n > 0; n-- {
+			node = findKey(file, err.segments[:n])
+		}
+		if node != nil {
+			setPosition(err, node)
+		}
+	}
+}
+
+// findKey returns the key of the field at segments, or nil if it isn't a key of a mapping.
+func findKey(file *ast.File, segments []any) ast.Node {
+	name, ok := segments[len(segments)-1].(string)
+	if !ok {
+		return nil
+	}
+
+	var parent ast.Node
+	if len(segments) == 1 {
+		for _, doc := range file.Docs {
+			if doc.Body != nil {
+				parent = doc.Body
+				break
+			}
+		}
+	} else {
+		parent = findNode(file, segments[:len(segments)-1])
+	}
+	for _, mv := range mappingValues(parent) {
+		if mappingKey(mv) == name {
+			return mv.Key
+		}
+	}
+	return nil
+}
+
+func findNode(file *ast.File, segments []any) ast.Node {
+	builder := (&yaml.PathBuilder{}).Root()
+	for _, s := range segments {
+		switch s := s.(type) {
+		case string:
+			builder = builder.Child(s)
+		case int:
+			builder = builder.Index(uint(s))
+		}
+	}
+
+	node, err := builder.Build().FilterFile(file)
+	if err != nil {
+		return nil
+	}
+	return node
+}
+
+// WithPath sets the file path of every ValidationError in err. Other errors are prefixed with the path.
+func WithPath(err error, path string) error {
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs := joined.Unwrap()
+			result := make([]error, 0, len(errs))
+			for _, e := range errs {
+				result = append(result, WithPath(e, path))
+			}
+			return errors.Join(result...)
+		}
+		validationErr.Path = path
+		return err
+	}
+
+	return fmt.Errorf("%s: %w", path, err)
+}
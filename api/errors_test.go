@@ -0,0 +1,104 @@
+package api
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validationErrors(t *testing.T, err error) []*ValidationError {
+	t.Helper()
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok, "error should be joined")
+
+	var result []*ValidationError
+	for _, e := range joined.Unwrap() {
+		var validationErr *ValidationError
+		require.ErrorAs(t, e, &validationErr)
+		result = append(result, validationErr)
+	}
+	return result
+}
+
+func TestUnmarshalTerraformConfiguration_AllErrorsWithPositions(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha0
+kind: TerraformConfiguration
+metadata:
+  name: Hello_World
+  labels:
+    tier: prod env
+spec:
+  dependsOn:
+    - network
+    - network_prod
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 4)
+
+	assert.Equal(t, "apiVersion", errs[0].Field)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, 13, errs[0].Column)
+
+	assert.Equal(t, "metadata.name", errs[1].Field)
+	assert.Equal(t, 5, errs[1].Line)
+
+	assert.Equal(t, "metadata.labels.tier", errs[2].Field)
+	assert.Equal(t, 7, errs[2].Line)
+
+	assert.Equal(t, "spec.dependsOn[1]", errs[3].Field)
+	assert.Equal(t, 11, errs[3].Line)
+	assert.Equal(t, `invalid spec.dependsOn "network_prod"`, errs[3].Message)
+}
+
+func TestUnmarshalTerraformConfiguration_MissingFieldPosition(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  labels:
+    tier: prod
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "metadata.name", validationErr.Field)
+	// metadata.name is missing, so the position of metadata is used.
+	assert.Equal(t, 4, validationErr.Line)
+	assert.Equal(t, 1, validationErr.Column)
+}
+
+func TestUnmarshalTerraformConfiguration_SyntaxErrorPosition(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: [TerraformConfiguration
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 3, validationErr.Line)
+}
+
+func TestWithPath(t *testing.T) {
+	err := WithPath(errors.Join(
+		&ValidationError{Line: 2, Column: 13, Field: "apiVersion", Message: "invalid version"},
+		&ValidationError{Field: "kind", Message: "invalid kind"},
+	), "a/pantalon.yaml")
+
+	assert.EqualError(t, err, "a/pantalon.yaml:2:13: invalid version\na/pantalon.yaml: invalid kind")
+}
+
+func TestWithPath_OtherError(t *testing.T) {
+	err := WithPath(errors.New("permission denied"), "a/pantalon.yaml")
+
+	assert.EqualError(t, err, "a/pantalon.yaml: permission denied")
+}
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// formatIndent is the indentation of each level of a formatted document.
+const formatIndent = "  "
+
+// Format returns a pantalon.yaml document in canonical form:
+//
+//   - a leading `---` before each document
+//   - known keys in the order of the schema of its apiVersion, followed by any unknown keys in their original order
+//   - block style, indented by two spaces, including sequences within a mapping
+//   - string context values, and every context value of v1alpha1, double quoted
+//   - no blank lines
+//
+// Each document of a file of several is formatted in turn. Comments are kept with the key or value they belong to.
+// The formatted document is read again and must describe the same configurations, with every comment.
+func Format(yamlDoc []byte) ([]byte, error) {
+	before, err := New().UnmarshalAll(yamlDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := parser.ParseBytes(yamlDoc, parser.ParseComments)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	f := &formatter{}
+	formatted := 0
+	for _, doc := range file.Docs {
+		switch n := doc.Body.(type) {
+		case nil:
+		case *ast.CommentGroupNode:
+			// Comments before a `---`, such as a yaml-language-server modeline.
+			if formatted == len(before) {
+				return nil, errors.New("comments after the document are not supported")
+			}
+			f.comments(n, "")
+		default:
+			if err := f.document(n, before[formatted].ApiVersion); err != nil {
+				return nil, err
+			}
+			formatted++
+		}
+	}
+	result := []byte(strings.Join(f.lines, "\n") + "\n")
+
+	after, err := New().UnmarshalAll(result)
+	if err != nil {
+		return nil, fmt.Errorf("formatted document is invalid: %w", err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		return nil, errors.New("formatting changed the configuration")
+	}
+	if !slices.Equal(documentComments(yamlDoc), documentComments(result)) {
+		return nil, errors.New("formatting would drop a comment")
+	}
+	return result, nil
+}
+
+// formatter writes the lines of a canonical document.
+type formatter struct {
+	lines []string
+}
+
+// document writes a document of apiVersion version, starting with `---`.
+func (f *formatter) document(body ast.Node, version string) error {
+	t, ok := configurationType(version)
+	if !ok {
+		return fmt.Errorf("unsupported apiVersion %q", version)
+	}
+
+	f.lines = append(f.lines, "---")
+
+	// Comments above the first key and below the last describe the document, so they stay at the top and bottom.
+	var footer *ast.CommentGroupNode
+	if entries := mappingValues(body); len(entries) > 0 {
+		f.comments(entries[0].GetComment(), "")
+		entries[0].Comment = nil
+		footer = entries[len(entries)-1].FootComment
+		entries[len(entries)-1].FootComment = nil
+	}
+
+	if err := f.mapping(body, t, "", ""); err != nil {
+		return err
+	}
+	f.comments(footer, "")
+	return nil
+}
+
+// mapping writes the entries of a mapping at indent. Known keys of a struct type t are sorted in the order of its
+// fields.
+func (f *formatter) mapping(node ast.Node, t reflect.Type, path string, indent string) error {
+	entries := slices.Clone(mappingValues(node))
+	if _, ok := node.(*ast.MappingNode); !ok && entries == nil {
+		return fmt.Errorf("%s must be a mapping", fieldOrDocument(path))
+	}
+
+	order := make(map[string]int)
+	if t != nil && t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if name, _, ok := yamlField(t.Field(i)); ok {
+				order[name] = len(order)
+			}
+		}
+	}
+	rank := func(mv *ast.MappingValueNode) int {
+		if i, ok := order[mappingKey(mv)]; ok {
+			return i
+		}
+		return len(order)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return rank(entries[i]) < rank(entries[j]) })
+
+	for _, mv := range entries {
+		key := mappingKey(mv)
+		f.comments(mv.GetComment(), indent)
+
+		var fieldType reflect.Type
+		if t != nil {
+			switch t.Kind() {
+			case reflect.Struct:
+				if field, ok := yamlFields(t)[key]; ok {
+					fieldType = field.Type
+				}
+			case reflect.Map:
+				fieldType = t.Elem()
+			}
+		}
+
+		prefix := indent + scalarText(mv.Key, false) + ":"
+		// Context values of v1alpha1 are always strings, while those of later versions may be of any type, so only a
+		// string is quoted, keeping its type.
+		_, isString := mv.Value.(*ast.StringNode)
+		quote := isContextPath(path) && (isString || fieldType != nil && fieldType.Kind() == reflect.String)
+		if err := f.value(prefix, inlineComment(mv.Key.GetComment()), mv.Value, fieldType, schemaPath(path, key), indent, quote); err != nil {
+			return err
+		}
+		f.comments(mv.FootComment, indent)
+	}
+
+	if m, ok := node.(*ast.MappingNode); ok {
+		f.comments(m.FootComment, indent)
+	}
+	return nil
+}
+
+// value writes the value of a key, starting on the line prefix.
+func (f *formatter) value(prefix, comment string, node ast.Node, t reflect.Type, path string, indent string, quote bool) error {
+	switch n := node.(type) {
+	case *ast.AnchorNode, *ast.AliasNode, *ast.TagNode:
+		return fmt.Errorf("%s: %s is not supported", path, strings.ToLower(node.Type().String()))
+	case *ast.MappingNode:
+		if len(n.Values) == 0 {
+			f.lines = append(f.lines, prefix+" {}"+comment+inlineComment(n.GetComment()))
+			return nil
+		}
+		f.lines = append(f.lines, prefix+comment+inlineComment(n.GetComment()))
+		return f.mapping(n, t, path, indent+formatIndent)
+	case *ast.MappingValueNode:
+		f.lines = append(f.lines, prefix+comment)
+		return f.mapping(n, t, path, indent+formatIndent)
+	case *ast.SequenceNode:
+		return f.sequence(prefix, comment, n, t, path, indent+formatIndent)
+	case *ast.LiteralNode:
+		f.lines = append(f.lines, prefix+" "+n.Start.Value+comment+inlineComment(n.GetComment()))
+		f.lines = append(f.lines, literalLines(n, indent+formatIndent)...)
+		return nil
+	case *ast.NullNode:
+		text := ""
+		if quote {
+			text = ` ""`
+		} else if tk := n.GetToken(); tk != nil && tk.Value != "" {
+			text = " " + tk.Value
+		}
+		f.lines = append(f.lines, prefix+text+comment+inlineComment(n.GetComment()))
+		return nil
+	case ast.ScalarNode:
+		f.lines = append(f.lines, prefix+" "+scalarText(n, quote)+comment+inlineComment(n.GetComment()))
+		return nil
+	case nil:
+		f.lines = append(f.lines, prefix+comment)
+		return nil
+	default:
+		return fmt.Errorf("%s: %s is not supported", path, strings.ToLower(node.Type().String()))
+	}
+}
+
+// sequence writes a sequence in block style, with each item at indent.
+func (f *formatter) sequence(prefix, comment string, n *ast.SequenceNode, t reflect.Type, path string, indent string) error {
+	if len(n.Values) == 0 {
+		f.lines = append(f.lines, prefix+" []"+comment+inlineComment(n.GetComment()))
+		return nil
+	}
+
+	var itemType reflect.Type
+	if t != nil && t.Kind() == reflect.Slice {
+		itemType = t.Elem()
+	}
+
+	// A comment on the sequence is on the line of a flow sequence, or above the first item of a block sequence.
+	if c := n.GetComment(); c != nil && n.IsFlowStyle {
+		comment += inlineComment(c)
+		f.lines = append(f.lines, prefix+comment)
+	} else {
+		f.lines = append(f.lines, prefix+comment)
+		f.comments(c, indent)
+	}
+
+	for i, item := range n.Values {
+		if i < len(n.ValueHeadComments) {
+			f.comments(n.ValueHeadComments[i], indent)
+		}
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		switch item := item.(type) {
+		case *ast.AnchorNode, *ast.AliasNode, *ast.TagNode:
+			return fmt.Errorf("%s: %s is not supported", itemPath, strings.ToLower(item.Type().String()))
+		case *ast.MappingNode, *ast.MappingValueNode:
+			start := len(f.lines)
+			if err := f.mapping(item, itemType, itemPath, indent+formatIndent); err != nil {
+				return err
+			}
+			// The first key shares the line of the `-`, after any comments above it.
+			for j := start; j < len(f.lines); j++ {
+				if !strings.HasPrefix(strings.TrimSpace(f.lines[j]), "#") {
+					f.lines[j] = indent + "- " + strings.TrimPrefix(f.lines[j], indent+formatIndent)
+					break
+				}
+			}
+		case *ast.LiteralNode:
+			f.lines = append(f.lines, indent+"- "+item.Start.Value+inlineComment(item.GetComment()))
+			f.lines = append(f.lines, literalLines(item, indent+formatIndent)...)
+		case ast.ScalarNode:
+			f.lines = append(f.lines, indent+"- "+scalarText(item, false)+inlineComment(item.GetComment()))
+		default:
+			return fmt.Errorf("%s: %s is not supported", itemPath, strings.ToLower(item.Type().String()))
+		}
+	}
+
+	f.comments(n.FootComment, indent)
+	return nil
+}
+
+// comments writes each comment of group on its own line at indent.
+func (f *formatter) comments(group *ast.CommentGroupNode, indent string) {
+	if group == nil {
+		return
+	}
+	for _, c := range group.Comments {
+		f.lines = append(f.lines, indent+c.String())
+	}
+}
+
+// inlineComment returns the comments of group to follow a value on the same line.
+func inlineComment(group *ast.CommentGroupNode) string {
+	if group == nil {
+		return ""
+	}
+	var result strings.Builder
+	for _, c := range group.Comments {
+		result.WriteString(" " + c.String())
+	}
+	return result.String()
+}
+
+// scalarText returns the text of a scalar, keeping its original quotes. With quote, a plain scalar is double quoted.
+func scalarText(node ast.Node, quote bool) string {
+	tk := node.GetToken()
+	origin := strings.TrimSpace(tk.Origin)
+	switch tk.Type {
+	case token.DoubleQuoteType, token.SingleQuoteType:
+		if !strings.Contains(origin, "\n") {
+			return origin
+		}
+		return strconv.Quote(tk.Value)
+	}
+	if quote {
+		return strconv.Quote(tk.Value)
+	}
+	return tk.Value
+}
+
+// literalLines returns the content of a block scalar, re-indented at indent.
+func literalLines(n *ast.LiteralNode, indent string) []string {
+	lines := strings.Split(n.String(), "\n")[1:]
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if n := len(line) - len(strings.TrimLeft(line, " ")); common < 0 || n < common {
+			common = n
+		}
+	}
+
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			result = append(result, "")
+			continue
+		}
+		result = append(result, indent+line[common:])
+	}
+	return result
+}
+
+func isContextPath(path string) bool {
+	return path == "context" || path == "spec.context"
+}
+
+func fieldOrDocument(path string) string {
+	if path == "" {
+		return "document"
+	}
+	return path
+}
+
+// documentComments returns the text of every comment in a document, sorted.
+func documentComments(yamlDoc []byte) []string {
+	var result []string
+	for _, tk := range lexer.Tokenize(string(yamlDoc)) {
+		if tk.Type == token.CommentType {
+			result = append(result, strings.TrimSpace(tk.Value))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "v1alpha1",
+			input: `# yaml-language-server: $schema=pantalon.schema.json
+---
+
+# Compute for production
+kind: TerraformConfiguration
+apiVersion: pantalon.kallan.dev/v1alpha1 # pinned
+spec:
+    dependsOn: [network-prod, "dns"]
+    ignore:
+    # docs
+    - "*.md"
+    - 'docs/**'
+
+context: # values
+  # The deploying account
+  gcp-service-account: infrastructure@pantalon-prod.iam.gserviceaccount.com
+  count: 1
+  enabled: true
+  empty:
+  quoted: 'it''s'
+  script: |
+      terraform init
+
+      terraform apply
+metadata:
+    labels: {tier: prod}
+    name: compute-prod
+# end of file
+`,
+			expected: `# yaml-language-server: $schema=pantalon.schema.json
+---
+# Compute for production
+apiVersion: pantalon.kallan.dev/v1alpha1 # pinned
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+  labels:
+    tier: prod
+context: # values
+  # The deploying account
+  gcp-service-account: "infrastructure@pantalon-prod.iam.gserviceaccount.com"
+  count: "1"
+  enabled: "true"
+  empty: ""
+  quoted: 'it''s'
+  script: |
+    terraform init
+
+    terraform apply
+spec:
+  dependsOn:
+    - network-prod
+    - "dns"
+  ignore:
+    # docs
+    - "*.md"
+    - 'docs/**'
+# end of file
+`,
+		},
+		{
+			name: "v1beta1",
+			input: `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+spec:
+  context:
+    region: ap-southeast-2
+  dependsOn:
+  - network-prod
+metadata: {name: compute-prod}
+`,
+			expected: `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  dependsOn:
+    - network-prod
+  context:
+    region: "ap-southeast-2"
+`,
+		},
+		{
+			name: "unknown fields last",
+			input: `---
+owner: platform
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+			expected: `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+owner: platform
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Format([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(result))
+
+			again, err := Format(result)
+			require.NoError(t, err)
+			assert.Equal(t, string(result), string(again), "formatting is idempotent")
+		})
+	}
+}
+
+func TestFormat_Unsupported(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+  labels: &labels
+    tier: prod
+spec:
+  context: *labels
+`
+	_, err := Format([]byte(yamlDoc))
+	assert.EqualError(t, err, "metadata.labels: anchor is not supported")
+}
+
+func TestFormat_Invalid(t *testing.T) {
+	_, err := Format([]byte("---\napiVersion: pantalon.kallan.dev/v1\n"))
+	assert.Error(t, err)
+}
+
+func TestFormat_MultipleDocuments(t *testing.T) {
+	input := `# yaml-language-server: $schema=pantalon.schema.json
+---
+kind: TerraformConfiguration
+apiVersion: pantalon.kallan.dev/v1beta1
+metadata: {name: compute-eu}
+---
+# The US workspace
+kind: TerraformConfiguration
+apiVersion: pantalon.kallan.dev/v1alpha1
+metadata: {name: compute-us}
+context:
+  workspace: us
+`
+	expected := `# yaml-language-server: $schema=pantalon.schema.json
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+---
+# The US workspace
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-us
+context:
+  workspace: "us"
+`
+	result, err := Format([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+}
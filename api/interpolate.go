@@ -0,0 +1,391 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidReference   = errors.New("invalid reference")
+	ErrUndefinedReference = errors.New("undefined reference")
+	ErrReferenceCycle     = errors.New("reference cycle")
+)
+
+// bareKeyRegexp matches a reference without a namespace, such as `${env}`.
+var bareKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// MarshalOptions control how configurations are marshaled into items.
+type MarshalOptions struct {
+	// Env holds the environment variables which context values may reference as `${env.NAME}`.
+	Env map[string]string
+}
+
+// referenceError is the error of the context key it occurred in, so a key referencing an invalid key doesn't report
+// the error again.
+type referenceError struct {
+	key string
+	err error
+}
+
+func (e *referenceError) Error() string { return e.err.Error() }
+func (e *referenceError) Unwrap() error { return e.err }
+
+// interpolator expands the references of the context values of a single item.
+//
+// A string context value, or a string within a list or object, may reference:
+//
+//   - `${name}`, the metadata.name
+//   - `${dir}`, the directory of the pantalon.yaml
+//   - `${segment.N}`, the Nth segment of the directory from 0, or from the end if negative
+//   - `${labels.KEY}`, a label
+//   - `${context.KEY}`, another context key, which must be a string, number or boolean
+//   - `${env.NAME}`, an environment variable in MarshalOptions.Env
+//   - `${KEY}`, a context key, or a label if there's no such context key
+//
+// Every other `${...}`, such as a GitHub Actions expression, is an error, and `$${` is a literal `${`. There are no
+// expressions, so every reference is a single lookup.
+type interpolator struct {
+	item ConfigurationItem
+	env  map[string]string
+
+	resolved map[string]any
+	failed   map[string]error
+	// resolving is the chain of context keys being resolved, to detect cycles.
+	resolving []string
+}
+
+// interpolate returns the context of item with every reference expanded.
+func (o MarshalOptions) interpolate(item ConfigurationItem) (map[string]any, error) {
+	if len(item.Context) == 0 {
+		return item.Context, nil
+	}
+
+	in := &interpolator{
+		item:     item,
+		env:      o.Env,
+		resolved: make(map[string]any, len(item.Context)),
+		failed:   make(map[string]error),
+	}
+
+	keys := make([]string, 0, len(item.Context))
+	for key := range item.Context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		_, err := in.key(key)
+		var refErr *referenceError
+		if errors.As(err, &refErr) && refErr.key == key {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return in.resolved, nil
+}
+
+// key returns the value of the context key with its references expanded.
+func (in *interpolator) key(key string) (any, error) {
+	if v, ok := in.resolved[key]; ok {
+		return v, nil
+	}
+	if err, ok := in.failed[key]; ok {
+		return nil, err
+	}
+
+	for i, k := range in.resolving {
+		if k == key {
+			var chain []string
+			for _, k := range in.resolving[i:] {
+				chain = append(chain, "context."+k)
+			}
+			chain = append(chain, "context."+key)
+			return nil, &referenceError{key: key, err: fmt.Errorf("context.%s: %w: %s", key, ErrReferenceCycle, strings.Join(chain, " -> "))}
+		}
+	}
+
+	in.resolving = append(in.resolving, key)
+	v, err := in.value(in.item.Context[key], "context."+key)
+	in.resolving = in.resolving[:len(in.resolving)-1]
+	if err != nil {
+		var refErr *referenceError
+		if !errors.As(err, &refErr) {
+			err = &referenceError{key: key, err: err}
+		}
+		in.failed[key] = err
+		return nil, err
+	}
+	in.resolved[key] = v
+	return v, nil
+}
+
+// value expands the references of every string within v.
+func (in *interpolator) value(v any, field string) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return in.expand(v, field)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := in.value(item, fmt.Sprintf("%s[%d]", field, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = expanded
+		}
+		return result, nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		result := make(map[string]any, len(v))
+		for _, key := range keys {
+			expanded, err := in.value(v[key], field+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = expanded
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+// expand replaces each reference in s with its value.
+func (in *interpolator) expand(s string, field string) (string, error) {
+	parts, err := parseReferences(s)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+
+	var result strings.Builder
+	for _, part := range parts {
+		if !part.reference {
+			result.WriteString(part.text)
+			continue
+		}
+		value, err := in.reference(part.text)
+		if err != nil {
+			var refErr *referenceError
+			if errors.As(err, &refErr) {
+				return "", err
+			}
+			return "", fmt.Errorf("%s: %w", field, err)
+		}
+		result.WriteString(value)
+	}
+	return result.String(), nil
+}
+
+// referencePart is a run of literal text, or a reference without the surrounding `${` and `}`.
+type referencePart struct {
+	text      string
+	reference bool
+}
+
+// parseReferences splits s into literal text and references, returning an error for the first `${...}` which isn't
+// a valid reference.
+func parseReferences(s string) ([]referencePart, error) {
+	var parts []referencePart
+	var text strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			text.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			text.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		text.WriteString(s[:i])
+
+		end := strings.Index(s[i:], "}")
+		if strings.HasPrefix(s[i:], "${{") {
+			// Include the whole of a GitHub Actions expression in the error.
+			if n := strings.Index(s[i:], "}}"); n >= 0 {
+				end = n + 1
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("%w %q: missing }", ErrInvalidReference, s[i:])
+		}
+		ref := strings.TrimSpace(s[i+2 : i+end])
+		if err := checkReference(ref, s[i:i+end+1]); err != nil {
+			return nil, err
+		}
+		if text.Len() > 0 {
+			parts = append(parts, referencePart{text: text.String()})
+			text.Reset()
+		}
+		parts = append(parts, referencePart{text: ref, reference: true})
+		s = s[i+end+1:]
+	}
+	if text.Len() > 0 {
+		parts = append(parts, referencePart{text: text.String()})
+	}
+	return parts, nil
+}
+
+// checkReference returns an error if ref, without the surrounding `${` and `}` of text, isn't one of the documented
+// references, such as a misspelt namespace.
+func checkReference(ref string, text string) error {
+	switch ref {
+	case "name", "dir":
+		return nil
+	}
+
+	namespace, key, ok := strings.Cut(ref, ".")
+	if !ok || strings.HasPrefix(ref, "{") {
+		if !bareKeyRegexp.MatchString(ref) {
+			return fmt.Errorf("%w %q, use $${ for a literal ${", ErrInvalidReference, text)
+		}
+		return nil
+	}
+	switch namespace {
+	case "segment":
+		if _, err := strconv.Atoi(key); err != nil {
+			return fmt.Errorf("%w %q: segment must be an integer", ErrInvalidReference, text)
+		}
+	case "labels", "env", "context":
+		if key == "" || strings.ContainsAny(key, " \t${}") {
+			return fmt.Errorf("%w %q", ErrInvalidReference, text)
+		}
+	default:
+		return fmt.Errorf("%w %q: unknown namespace %q, use $${ for a literal ${", ErrInvalidReference, text, namespace)
+	}
+	return nil
+}
+
+// validateReferences returns an error for every string within context with an invalid reference, positioned at
+// the context, which is at path within the document.
+func validateReferences(context map[string]any, path []any) []*ValidationError {
+	keys := make([]string, 0, len(context))
+	for key := range context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []*ValidationError
+	for _, key := range keys {
+		errs = append(errs, validateValueReferences(context[key], appendSegment(path, key))...)
+	}
+	return errs
+}
+
+func validateValueReferences(v any, segments []any) []*ValidationError {
+	switch v := v.(type) {
+	case string:
+		if _, err := parseReferences(v); err != nil {
+			return []*ValidationError{newValidationError(fmt.Sprintf("%s: %s", fieldName(segments), err), segments...)}
+		}
+	case []any:
+		var errs []*ValidationError
+		for i, item := range v {
+			errs = append(errs, validateValueReferences(item, appendSegment(segments, i))...)
+		}
+		return errs
+	case map[string]any:
+		return validateReferences(v, segments)
+	}
+	return nil
+}
+
+// reference returns the value of a single reference, without the surrounding `${` and `}`.
+func (in *interpolator) reference(ref string) (string, error) {
+	undefined := fmt.Errorf("%w %q", ErrUndefinedReference, "${"+ref+"}")
+
+	switch ref {
+	case "name":
+		return in.item.Name, nil
+	case "dir":
+		return in.item.Dir, nil
+	}
+
+	namespace, key, ok := strings.Cut(ref, ".")
+	if !ok {
+		// A bare key is a context key, or a label if there's no such context key.
+		if _, ok := in.item.Context[ref]; ok {
+			v, err := in.key(ref)
+			if err != nil {
+				return "", err
+			}
+			return scalarString(v, ref)
+		}
+		if v, ok := in.item.Labels[ref]; ok {
+			return v, nil
+		}
+		return "", undefined
+	}
+	if key == "" {
+		return "", undefined
+	}
+	switch namespace {
+	case "segment":
+		n, err := strconv.Atoi(key)
+		if err != nil {
+			return "", undefined
+		}
+		var segments []string
+		if in.item.Dir != "." && in.item.Dir != "" {
+			segments = strings.Split(in.item.Dir, "/")
+		}
+		if n < 0 {
+			n += len(segments)
+		}
+		if n < 0 || n >= len(segments) {
+			return "", undefined
+		}
+		return segments[n], nil
+	case "labels":
+		if v, ok := in.item.Labels[key]; ok {
+			return v, nil
+		}
+	case "env":
+		if v, ok := in.env[key]; ok {
+			return v, nil
+		}
+	case "context":
+		if _, ok := in.item.Context[key]; !ok {
+			return "", undefined
+		}
+		v, err := in.key(key)
+		if err != nil {
+			return "", err
+		}
+		return scalarString(v, ref)
+	}
+	return "", undefined
+}
+
+// scalarString returns the text of a string, number or boolean context value.
+func scalarString(v any, ref string) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("reference %q: %s is not a string, number or boolean", "${"+ref+"}", ref)
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalItems_Interpolation(t *testing.T) {
+	cfg := TerraformConfiguration{
+		Metadata: Metadata{Name: "compute-prod", Labels: map[string]string{"tier": "prod"}},
+		Path:     "terraform/compute/environments/prod/pantalon.yaml",
+		Context: map[string]any{
+			"account":  "infrastructure@pantalon-${segment.-1}.iam.gserviceaccount.com",
+			"project":  "pantalon-${labels.tier}",
+			"bucket":   "${context.project}-state-${context.replicas}",
+			"replicas": uint64(3),
+			"enabled":  true,
+			"regions":  []any{"${env.REGION}", "us-east1"},
+			"runner":   map[string]any{"name": "${name}", "dir": "${dir}", "component": "${ segment.1 }"},
+			"literal":  "$${name}",
+		},
+	}
+
+	items, err := MarshalOptions{Env: map[string]string{"REGION": "europe-west1"}}.MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	assert.Equal(t, map[string]any{
+		"account":  "infrastructure@pantalon-prod.iam.gserviceaccount.com",
+		"project":  "pantalon-prod",
+		"bucket":   "pantalon-prod-state-3",
+		"replicas": uint64(3),
+		"enabled":  true,
+		"regions":  []any{"europe-west1", "us-east1"},
+		"runner":   map[string]any{"name": "compute-prod", "dir": "terraform/compute/environments/prod", "component": "compute"},
+		"literal":  "${name}",
+	}, items[0].Context)
+	assert.Equal(t, "infrastructure@pantalon-${segment.-1}.iam.gserviceaccount.com", cfg.Context["account"])
+}
+
+func TestMarshalItems_InterpolationBareKey(t *testing.T) {
+	cfg := TerraformConfiguration{
+		Metadata: Metadata{Name: "compute-prod", Labels: map[string]string{"tier": "prod"}},
+		Path:     "terraform/compute/environments/prod/pantalon.yaml",
+		Context: map[string]any{
+			"env":     "${segment.-1}",
+			"account": "infrastructure@pantalon-${env}.iam.gserviceaccount.com",
+			"bucket":  "${tier}-state",
+		},
+	}
+
+	items, err := MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"env":     "prod",
+		"account": "infrastructure@pantalon-prod.iam.gserviceaccount.com",
+		"bucket":  "prod-state",
+	}, items[0].Context)
+}
+
+func TestMarshalItems_InterpolationEscape(t *testing.T) {
+	context := map[string]any{
+		"token": "$${{ secrets.TOKEN }}",
+		"tf":    "$${var.region}",
+		"mixed": "$${{ matrix.env }}-${name}",
+	}
+	cfg := TerraformConfiguration{Metadata: Metadata{Name: "b"}, Path: "a/b/pantalon.yaml", Context: context}
+
+	items, err := MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"token": "${{ secrets.TOKEN }}",
+		"tf":    "${var.region}",
+		"mixed": "${{ matrix.env }}-b",
+	}, items[0].Context)
+}
+
+func TestMarshalItems_InterpolationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		context  map[string]any
+		expected string
+		target   error
+	}{
+		{
+			name:     "undefined label",
+			context:  map[string]any{"project": "pantalon-${labels.env}"},
+			expected: `a/b/pantalon.yaml: context.project: undefined reference "${labels.env}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "environment variable not allowed",
+			context:  map[string]any{"home": "${env.HOME}"},
+			expected: `a/b/pantalon.yaml: context.home: undefined reference "${env.HOME}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "segment out of range",
+			context:  map[string]any{"env": "${segment.2}"},
+			expected: `a/b/pantalon.yaml: context.env: undefined reference "${segment.2}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "unknown reference within a list",
+			context:  map[string]any{"regions": []any{"${labels.region}"}},
+			expected: `a/b/pantalon.yaml: context.regions[0]: undefined reference "${labels.region}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "undefined bare key",
+			context:  map[string]any{"account": "pantalon-${env}"},
+			expected: `a/b/pantalon.yaml: context.account: undefined reference "${env}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "unknown namespace",
+			context:  map[string]any{"project": "pantalon-${contxt.env}"},
+			expected: `a/b/pantalon.yaml: context.project: invalid reference "${contxt.env}": unknown namespace "contxt", use $${ for a literal ${`,
+			target:   ErrInvalidReference,
+		},
+		{
+			name:     "unescaped expression",
+			context:  map[string]any{"token": "${{ secrets.TOKEN }}"},
+			expected: `a/b/pantalon.yaml: context.token: invalid reference "${{ secrets.TOKEN }}", use $${ for a literal ${`,
+			target:   ErrInvalidReference,
+		},
+		{
+			name:     "unterminated",
+			context:  map[string]any{"project": "pantalon-${name"},
+			expected: `a/b/pantalon.yaml: context.project: invalid reference "${name": missing }`,
+			target:   ErrInvalidReference,
+		},
+		{
+			name:     "cycle",
+			context:  map[string]any{"a": "${context.b}", "b": "${context.c}", "c": "${context.a}"},
+			expected: `a/b/pantalon.yaml: context.a: reference cycle: context.a -> context.b -> context.c -> context.a`,
+			target:   ErrReferenceCycle,
+		},
+		{
+			name:     "self reference",
+			context:  map[string]any{"a": "${context.a}"},
+			expected: `a/b/pantalon.yaml: context.a: reference cycle: context.a -> context.a`,
+			target:   ErrReferenceCycle,
+		},
+		{
+			name:     "reference to an invalid key",
+			context:  map[string]any{"a": "${context.b}", "b": "${labels.env}"},
+			expected: `a/b/pantalon.yaml: context.b: undefined reference "${labels.env}"`,
+			target:   ErrUndefinedReference,
+		},
+		{
+			name:     "reference to a list",
+			context:  map[string]any{"a": "${context.b}", "b": []any{"x"}},
+			expected: `a/b/pantalon.yaml: context.a: reference "${context.b}": context.b is not a string, number or boolean`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := TerraformConfiguration{Metadata: Metadata{Name: "b"}, Path: "a/b/pantalon.yaml", Context: tt.context}
+
+			_, err := MarshalItems([]TerraformConfiguration{cfg})
+			assert.EqualError(t, err, tt.expected)
+			if tt.target != nil {
+				assert.ErrorIs(t, err, tt.target)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTerraformConfiguration_InvalidReference(t *testing.T) {
+	tests := []struct {
+		name    string
+		yamlDoc string
+		field   string
+		line    int
+	}{
+		{
+			name: "v1beta1",
+			yamlDoc: `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    regions:
+      - us-east1
+      - ${contxt.region}
+`,
+			field: "spec.context.regions[1]",
+			line:  9,
+		},
+		{
+			name: "v1alpha1",
+			yamlDoc: `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+context:
+  project: pantalon-${contxt.env}
+`,
+			field: "context.project",
+			line:  6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New().Unmarshal([]byte(tt.yamlDoc))
+
+			errs := validationErrors(t, err)
+			require.Len(t, errs, 1)
+			assert.Equal(t, tt.field, errs[0].Field)
+			assert.Equal(t, tt.line, errs[0].Line)
+			assert.Contains(t, errs[0].Message, `unknown namespace "contxt"`)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// labelNamePattern matches a label name, or a non-empty label value.
+const labelNamePattern = `([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]`
+
+var labelNameRegexp = regexp.MustCompile(`^` + labelNamePattern + `$`)
+
+// validateLabels checks every key and value in metadata.labels.
+//
+// As described in https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#syntax-and-character-set
+func validateLabels(labels map[string]string) []*ValidationError {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []*ValidationError
+	for _, k := range keys {
+		if !isValidLabelKey(k) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid metadata.labels key %q", k), "metadata", "labels", k))
+		} else if !isValidLabelValue(labels[k]) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid metadata.labels value %q for key %q", labels[k], k), "metadata", "labels", k))
+		}
+	}
+	return errs
+}
+
+// A label key is an optional DNS subdomain prefix and a name, separated by a slash.
+func isValidLabelKey(s string) bool {
+	name := s
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		prefix := s[:i]
+		name = s[i+1:]
+		if !isValidSubdomain(prefix) {
+			return false
+		}
+	}
+
+	return len(name) <= 63 && labelNameRegexp.MatchString(name)
+}
+
+// A label value may be empty, otherwise it follows the same rules as a label name.
+func isValidLabelValue(s string) bool {
+	if s == "" {
+		return true
+	}
+	return len(s) <= 63 && labelNameRegexp.MatchString(s)
+}
+
+// Must comply with RFC 1123 subdomains, a series of labels separated by dots
+func isValidSubdomain(s string) bool {
+	if len(s) == 0 || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if len(label) > 63 || !isValidSubdomainLabel(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	matrixInclude = "include"
+	matrixExclude = "exclude"
+)
+
+// matrixAxis is a key of spec.matrix with the values it takes.
+type matrixAxis struct {
+	key    string
+	values []any
+}
+
+// matrix is a parsed spec.matrix, which expands a configuration into an item for each combination of the values of
+// its axes, like the matrix of a GitHub Actions job.
+type matrix struct {
+	// axes are in the order they're declared, which is the order of the values in derived names.
+	axes    []matrixAxis
+	include []map[string]any
+	exclude []map[string]any
+}
+
+// parseMatrix parses spec.matrix, returning an error for every invalid key.
+func parseMatrix(m yaml.MapSlice) (matrix, []*ValidationError) {
+	var result matrix
+	var errs []*ValidationError
+	for _, item := range m {
+		key := fmt.Sprint(item.Key)
+		switch key {
+		case matrixInclude, matrixExclude:
+			entries, entryErrs := parseMatrixEntries(key, item.Value)
+			errs = append(errs, entryErrs...)
+			if key == matrixInclude {
+				result.include = entries
+			} else {
+				result.exclude = entries
+			}
+		default:
+			values, ok := item.Value.([]any)
+			if !ok || len(values) == 0 {
+				errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix.%s: must be a list of values", key), "spec", "matrix", key))
+				continue
+			}
+			for i, v := range values {
+				if !isMatrixScalar(v) {
+					errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix.%s[%d]: must be a string, number or boolean", key, i), "spec", "matrix", key, i))
+				}
+			}
+			result.axes = append(result.axes, matrixAxis{key: key, values: values})
+		}
+	}
+	if len(errs) > 0 {
+		return result, errs
+	}
+
+	for i, entry := range result.exclude {
+		for key, v := range entry {
+			if !isMatrixScalar(v) {
+				errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix.exclude[%d].%s: must be a string, number or boolean", i, key), "spec", "matrix", matrixExclude, i, key))
+			}
+		}
+	}
+	for i, entry := range result.include {
+		for _, axis := range result.axes {
+			if v, ok := entry[axis.key]; ok && !isMatrixScalar(v) {
+				errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix.include[%d].%s: must be a string, number or boolean", i, axis.key), "spec", "matrix", matrixInclude, i, axis.key))
+			}
+		}
+	}
+	return result, errs
+}
+
+func parseMatrixEntries(key string, value any) ([]map[string]any, []*ValidationError) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, []*ValidationError{newValidationError(fmt.Sprintf("invalid spec.matrix.%s: must be a list of mappings", key), "spec", "matrix", key)}
+	}
+
+	var entries []map[string]any
+	var errs []*ValidationError
+	for i, v := range list {
+		entry, ok := v.(map[string]any)
+		if !ok {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix.%s[%d]: must be a mapping", key, i), "spec", "matrix", key, i))
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, errs
+}
+
+func isMatrixScalar(v any) bool {
+	switch v.(type) {
+	case string, bool, int, int64, uint64, float64:
+		return true
+	}
+	return false
+}
+
+// combinations returns every combination of the values of the axes, with the first axis changing slowest, less those
+// matching an exclude entry.
+//
+// Each include entry is then merged into every combination it matches, without changing the value of an axis. An
+// entry which matches none is added as a combination of its own.
+func (m matrix) combinations() []map[string]any {
+	var result []map[string]any
+	if len(m.axes) > 0 {
+		result = []map[string]any{{}}
+		for _, axis := range m.axes {
+			next := make([]map[string]any, 0, len(result)*len(axis.values))
+			for _, combination := range result {
+				for _, v := range axis.values {
+					c := make(map[string]any, len(combination)+1)
+					for k, v := range combination {
+						c[k] = v
+					}
+					c[axis.key] = v
+					next = append(next, c)
+				}
+			}
+			result = next
+		}
+	}
+
+	kept := result[:0]
+	for _, combination := range result {
+		excluded := false
+		for _, entry := range m.exclude {
+			if matchesEntry(combination, entry) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, combination)
+		}
+	}
+	result = kept
+
+	original := len(result)
+	for _, entry := range m.include {
+		matched := false
+		for _, combination := range result[:original] {
+			if !m.includes(combination, entry) {
+				continue
+			}
+			matched = true
+			for k, v := range entry {
+				combination[k] = v
+			}
+		}
+		if !matched {
+			c := make(map[string]any, len(entry))
+			for k, v := range entry {
+				c[k] = v
+			}
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// matchesEntry reports whether every key of entry has the same value in combination.
+func matchesEntry(combination, entry map[string]any) bool {
+	for k, v := range entry {
+		if !reflect.DeepEqual(combination[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// includes reports whether an include entry can be merged into combination, without changing the value of an axis.
+func (m matrix) includes(combination, entry map[string]any) bool {
+	for _, axis := range m.axes {
+		if v, ok := entry[axis.key]; ok && !reflect.DeepEqual(combination[axis.key], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// name returns the name of the item of combination, which is name followed by the value of each of its axes.
+func (m matrix) name(name string, combination map[string]any) string {
+	parts := []string{name}
+	for _, axis := range m.axes {
+		if v, ok := combination[axis.key]; ok {
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// validateMatrix returns an error for every invalid field of the spec.matrix of cfg, or for an item name derived
+// from it which isn't valid.
+func validateMatrix(cfg TerraformConfiguration) []*ValidationError {
+	if cfg.Spec.Matrix == nil {
+		return nil
+	}
+
+	m, errs := parseMatrix(cfg.Spec.Matrix)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	combinations := m.combinations()
+	if len(combinations) == 0 {
+		return []*ValidationError{newValidationError("invalid spec.matrix: every combination is excluded", "spec", "matrix")}
+	}
+	for _, combination := range combinations {
+		if name := m.name(cfg.Metadata.Name, combination); !isValidSubdomainLabel(name) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.matrix: derived name %q is not a valid metadata.name", name), "spec", "matrix"))
+		}
+	}
+	return errs
+}
+
+// expandMatrix returns an item for each combination of spec.matrix, or item itself without a matrix. The values of
+// each combination are merged into the context of its item, taking precedence.
+func expandMatrix(item ConfigurationItem, spec yaml.MapSlice) ([]ConfigurationItem, error) {
+	if spec == nil {
+		return []ConfigurationItem{item}, nil
+	}
+
+	m, errs := parseMatrix(spec)
+	if len(errs) > 0 {
+		return nil, WithPath(joinValidationErrors(errs), item.Path)
+	}
+
+	combinations := m.combinations()
+	result := make([]ConfigurationItem, 0, len(combinations))
+	for _, combination := range combinations {
+		expanded := item
+		expanded.Name = m.name(item.Name, combination)
+		expanded.Matrix = combination
+		expanded.Context = maps.Clone(item.Context)
+		if expanded.Context == nil {
+			expanded.Context = make(map[string]any, len(combination))
+		}
+		expanded.ContextSources = maps.Clone(item.ContextSources)
+		for k, v := range combination {
+			expanded.Context[k] = v
+			delete(expanded.ContextSources, k)
+		}
+		if len(expanded.ContextSources) == 0 {
+			expanded.ContextSources = nil
+		}
+		result = append(result, expanded)
+	}
+	return result, nil
+}
+
+// expandDependencies replaces each dependency on a configuration with a matrix, keyed by its metadata.name in
+// expanded, with a dependency on every item of the matrix.
+func expandDependencies(items []ConfigurationItem, expanded map[string][]string) []ConfigurationItem {
+	if len(expanded) == 0 {
+		return items
+	}
+
+	for i, item := range items {
+		var dependsOn []string
+		for _, dep := range item.DependsOn {
+			if names, ok := expanded[dep]; ok {
+				dependsOn = append(dependsOn, names...)
+			} else {
+				dependsOn = append(dependsOn, dep)
+			}
+		}
+		items[i].DependsOn = dependsOn
+	}
+	return items
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalItems_Matrix(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    workspace: default
+    account: infrastructure@${name}.iam.gserviceaccount.com
+  matrix:
+    workspace: [eu, us]
+    tier: [web, batch]
+    exclude:
+      - workspace: us
+        tier: batch
+    include:
+      - workspace: eu
+        replicas: 3
+      - workspace: ap
+        tier: web
+`
+	cfg, err := NewStrict().Unmarshal([]byte(yamlDoc))
+	require.NoError(t, err)
+	cfg.Path = "compute/pantalon.yaml"
+	cfg.ContextSources = map[string]string{"workspace": "pantalon.defaults.yaml"}
+
+	items, err := MarshalItems([]TerraformConfiguration{cfg})
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	assert.Equal(t, []string{"compute-prod-eu-web", "compute-prod-eu-batch", "compute-prod-us-web", "compute-prod-ap-web"}, names)
+
+	assert.Equal(t, map[string]any{"workspace": "eu", "tier": "web", "replicas": uint64(3)}, items[0].Matrix)
+	assert.Equal(t, map[string]any{
+		"workspace": "eu",
+		"tier":      "web",
+		"replicas":  uint64(3),
+		"account":   "infrastructure@compute-prod-eu-web.iam.gserviceaccount.com",
+	}, items[0].Context)
+	assert.Nil(t, items[0].ContextSources)
+	assert.Equal(t, map[string]any{"workspace": "us", "tier": "web"}, items[2].Matrix)
+	assert.Equal(t, "compute", items[3].Dir)
+	assert.Equal(t, "infrastructure@${name}.iam.gserviceaccount.com", cfg.Context["account"])
+}
+
+func TestMarshalItems_MatrixDependencies(t *testing.T) {
+	cfgs := []TerraformConfiguration{
+		{Metadata: Metadata{Name: "network"}, Path: "network/pantalon.yaml", Spec: Spec{Matrix: matrixSpec(t, "region: [eu, us]")}},
+		{Metadata: Metadata{Name: "compute"}, Path: "compute/pantalon.yaml", Spec: Spec{DependsOn: []string{"network", "dns"}}},
+	}
+
+	items, err := MarshalItems(cfgs)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, []string{"network-eu", "network-us", "dns"}, items[2].DependsOn)
+}
+
+func TestUnmarshal_MatrixErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		matrix   string
+		expected []string
+	}{
+		{
+			name:     "axis not a list",
+			matrix:   "workspace: eu",
+			expected: []string{"invalid spec.matrix.workspace: must be a list of values"},
+		},
+		{
+			name:     "axis value not a scalar",
+			matrix:   "workspace: [eu, [us]]",
+			expected: []string{"invalid spec.matrix.workspace[1]: must be a string, number or boolean"},
+		},
+		{
+			name:     "include not a list of mappings",
+			matrix:   "workspace: [eu]\ninclude: [eu]",
+			expected: []string{"invalid spec.matrix.include[0]: must be a mapping"},
+		},
+		{
+			name:     "every combination excluded",
+			matrix:   "workspace: [eu]\nexclude: [{workspace: eu}]",
+			expected: []string{"invalid spec.matrix: every combination is excluded"},
+		},
+		{
+			name:     "invalid derived name",
+			matrix:   "workspace: [EU]",
+			expected: []string{`invalid spec.matrix: derived name "compute-EU" is not a valid metadata.name`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yamlDoc := "apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: compute\nspec:\n  matrix:\n" + indent(tt.matrix, "    ")
+			_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+			errs := validationErrors(t, err)
+			messages := make([]string, 0, len(errs))
+			for _, e := range errs {
+				messages = append(messages, e.Message)
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+func TestMatrixCombinations(t *testing.T) {
+	tests := []struct {
+		name     string
+		matrix   string
+		expected []map[string]any
+	}{
+		{
+			name:   "first axis changes slowest",
+			matrix: "a: [1, 2]\nb: [x, y]",
+			expected: []map[string]any{
+				{"a": uint64(1), "b": "x"},
+				{"a": uint64(1), "b": "y"},
+				{"a": uint64(2), "b": "x"},
+				{"a": uint64(2), "b": "y"},
+			},
+		},
+		{
+			name:   "include doesn't change an axis",
+			matrix: "a: [x, y]\ninclude: [{a: x, b: 1}, {b: 2}]",
+			expected: []map[string]any{
+				{"a": "x", "b": uint64(2)},
+				{"a": "y", "b": uint64(2)},
+			},
+		},
+		{
+			name:     "include only",
+			matrix:   "include: [{a: x}, {a: y}]",
+			expected: []map[string]any{{"a": "x"}, {"a": "y"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, errs := parseMatrix(matrixSpec(t, tt.matrix))
+			require.Empty(t, errs)
+			assert.Equal(t, tt.expected, m.combinations())
+		})
+	}
+}
+
+// matrixSpec decodes a spec.matrix from its YAML.
+func matrixSpec(t *testing.T, matrix string) yaml.MapSlice {
+	var spec Spec
+	require.NoError(t, yaml.Unmarshal([]byte("matrix:\n"+indent(matrix, "  ")), &spec))
+	return spec.Matrix
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+// migrationStep rewrites a document of one apiVersion as the next.
+type migrationStep struct {
+	from    string
+	to      string
+	rewrite func(doc *migrationDocument) error
+}
+
+// migrationSteps are applied in turn until a document is LatestVersion. A new apiVersion adds a step from the
+// previous latest version.
+var migrationSteps = []migrationStep{
+	{from: V1Alpha1, to: V1Beta1, rewrite: migrateV1Alpha1ToV1Beta1},
+}
+
+// v1beta1 moves context within spec. Context values of v1alpha1 are always strings, so any other scalar is quoted to
+// keep it a string.
+func migrateV1Alpha1ToV1Beta1(doc *migrationDocument) error {
+	if err := doc.setValue("apiVersion", V1Beta1); err != nil {
+		return err
+	}
+	if err := doc.quoteScalars("context"); err != nil {
+		return err
+	}
+	return doc.moveInto("context", "spec")
+}
+
+// Migrate rewrites a pantalon.yaml as LatestVersion, migrating each of its documents in turn. Only the lines which
+// must change are rewritten, so comments, formatting and the order of keys are preserved. A document of
+// LatestVersion is returned unchanged.
+//
+// Each migrated document is read again and must describe the same configuration.
+func Migrate(yamlDoc []byte) ([]byte, error) {
+	chunks, err := splitDocuments(string(yamlDoc))
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) <= 1 {
+		return migrateDocument(yamlDoc)
+	}
+
+	// Report invalid documents positioned within the file, rather than within their own lines.
+	if _, err := New().UnmarshalAll(yamlDoc); err != nil {
+		return nil, err
+	}
+
+	migrated := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		result, err := migrateDocument([]byte(chunk))
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		migrated = append(migrated, string(result))
+	}
+	return []byte(strings.Join(migrated, "\n")), nil
+}
+
+// splitDocuments splits src into the lines of each document with content, starting at its `---`. Comments and
+// documents without content are kept with the document before them, or the first.
+func splitDocuments(src string) ([]string, error) {
+	file, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	var starts []int
+	for _, doc := range file.Docs {
+		switch doc.Body.(type) {
+		case nil, *ast.CommentGroupNode:
+			continue
+		}
+		if len(starts) > 0 && (doc.Start == nil || doc.Start.Position == nil) {
+			return nil, errors.New("expected `---` before each document")
+		}
+		if len(starts) == 0 {
+			starts = append(starts, 0)
+		} else {
+			starts = append(starts, doc.Start.Position.Line-1)
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	chunks := make([]string, 0, len(starts))
+	for i, start := range starts {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		chunks = append(chunks, strings.Join(lines[start:end], "\n"))
+	}
+	return chunks, nil
+}
+
+// migrateDocument rewrites a single pantalon.yaml document as LatestVersion.
+func migrateDocument(yamlDoc []byte) ([]byte, error) {
+	before, err := New().Unmarshal(yamlDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := newMigrationDocument(string(yamlDoc))
+	if err != nil {
+		return nil, err
+	}
+
+	for version := before.ApiVersion; version != LatestVersion; {
+		step, ok := findMigrationStep(version)
+		if !ok {
+			return nil, fmt.Errorf("no migration from apiVersion %s", version)
+		}
+		if err := step.rewrite(doc); err != nil {
+			return nil, fmt.Errorf("migrating from %s to %s: %w", step.from, step.to, err)
+		}
+		version = step.to
+	}
+
+	result := []byte(doc.String())
+	after, err := New().Unmarshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("migrated document is invalid: %w", err)
+	}
+
+	before.ApiVersion = after.ApiVersion
+	if !reflect.DeepEqual(before, after) {
+		return nil, errors.New("migration changed the configuration")
+	}
+	return result, nil
+}
+
+func findMigrationStep(version string) (migrationStep, bool) {
+	for _, step := range migrationSteps {
+		if step.from == version {
+			return step, true
+		}
+	}
+	return migrationStep{}, false
+}
+
+// migrationDocument is the source lines of a pantalon.yaml, with the top level keys of its document used to find
+// the lines of each field.
+type migrationDocument struct {
+	lines []string
+	keys  []*ast.MappingValueNode
+}
+
+func newMigrationDocument(src string) (*migrationDocument, error) {
+	doc := &migrationDocument{}
+	return doc, doc.parse(src)
+}
+
+func (d *migrationDocument) parse(src string) error {
+	file, err := parser.ParseBytes([]byte(src), parser.ParseComments)
+	if err != nil {
+		return decodeError(err)
+	}
+	var bodies []ast.Node
+	for _, doc := range file.Docs {
+		switch doc.Body.(type) {
+		case nil, *ast.CommentGroupNode:
+		default:
+			bodies = append(bodies, doc.Body)
+		}
+	}
+	if len(bodies) != 1 {
+		return fmt.Errorf("expected a single document, found %d", len(bodies))
+	}
+
+	d.lines = strings.Split(src, "\n")
+	d.keys = mappingValues(bodies[0])
+	return nil
+}
+
+func (d *migrationDocument) String() string {
+	return strings.Join(d.lines, "\n")
+}
+
+// key returns the index of the top level key name.
+func (d *migrationDocument) key(name string) (int, bool) {
+	for i, mv := range d.keys {
+		if mappingKey(mv) == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// keyLine returns the 0-based line of the top level key i.
+func (d *migrationDocument) keyLine(i int) int {
+	return d.keys[i].Key.GetToken().Position.Line - 1
+}
+
+// block returns the lines of the top level key i, including the comments directly above it but not blank lines or
+// comments after it. The comments above the first key are the header of the document, so they aren't included.
+func (d *migrationDocument) block(i int) (start, end int) {
+	start = d.keyLine(i)
+	for i > 0 && start > 0 && strings.HasPrefix(d.lines[start-1], "#") {
+		start--
+	}
+
+	end = len(d.lines)
+	if i+1 < len(d.keys) {
+		end, _ = d.block(i + 1)
+	}
+	for end > start+1 && (strings.TrimSpace(d.lines[end-1]) == "" || strings.HasPrefix(d.lines[end-1], "#")) {
+		end--
+	}
+	return start, end
+}
+
+// setValue replaces the scalar value of the top level key name, keeping any quotes and comments.
+func (d *migrationDocument) setValue(name, value string) error {
+	i, ok := d.key(name)
+	if !ok {
+		return fmt.Errorf("missing %s", name)
+	}
+
+	tk := d.keys[i].Value.GetToken()
+	if _, ok := d.keys[i].Value.(ast.ScalarNode); !ok || tk == nil || tk.Position == nil {
+		return fmt.Errorf("%s is not a scalar", name)
+	}
+
+	line := tk.Position.Line - 1
+	col := tk.Position.Column - 1
+	offset := strings.Index(d.lines[line][col:], tk.Value)
+	if offset < 0 {
+		return fmt.Errorf("%s is not on a single line", name)
+	}
+	offset += col
+	d.lines[line] = d.lines[line][:offset] + value + d.lines[line][offset+len(tk.Value):]
+	return d.parse(d.String())
+}
+
+// quoteScalars double quotes each number, boolean or null value of the mapping of the top level key name, so it's
+// read as a string.
+func (d *migrationDocument) quoteScalars(name string) error {
+	i, ok := d.key(name)
+	if !ok {
+		return nil
+	}
+
+	values := mappingValues(d.keys[i].Value)
+	// Edit from the end, so the position of each earlier value is unchanged.
+	for j := len(values) - 1; j >= 0; j-- {
+		mv := values[j]
+		switch value := mv.Value.(type) {
+		case *ast.StringNode, *ast.LiteralNode:
+			continue
+		case *ast.NullNode:
+			tk := mv.Key.GetToken()
+			line := tk.Position.Line - 1
+			colon := strings.Index(d.lines[line][tk.Position.Column-1:], ":")
+			if colon < 0 {
+				return fmt.Errorf("%s.%s is not on a single line", name, mappingKey(mv))
+			}
+			colon += tk.Position.Column - 1
+			rest := strings.TrimPrefix(strings.TrimLeft(d.lines[line][colon+1:], " "), value.GetToken().Value)
+			d.lines[line] = d.lines[line][:colon+1] + ` ""` + strings.TrimRight(" "+strings.TrimLeft(rest, " "), " ")
+		case ast.ScalarNode:
+			tk := value.GetToken()
+			if tk == nil || tk.Position == nil {
+				return fmt.Errorf("%s.%s is not a scalar", name, mappingKey(mv))
+			}
+			line := tk.Position.Line - 1
+			col := tk.Position.Column - 1
+			offset := strings.Index(d.lines[line][col:], tk.Value)
+			if offset < 0 {
+				return fmt.Errorf("%s.%s is not on a single line", name, mappingKey(mv))
+			}
+			offset += col
+			d.lines[line] = d.lines[line][:offset] + `"` + tk.Value + `"` + d.lines[line][offset+len(tk.Value):]
+		}
+	}
+	return d.parse(d.String())
+}
+
+// moveInto moves the top level key name, with its comments, to the end of the block mapping of the top level key
+// parent, which is created in its place if missing.
+func (d *migrationDocument) moveInto(name, parent string) error {
+	i, ok := d.key(name)
+	if !ok {
+		return nil
+	}
+	start, end := d.block(i)
+
+	p, ok := d.key(parent)
+	if !ok {
+		moved := append([]string{parent + ":"}, indentLines(d.lines[start:end], "  ")...)
+		return d.parse(strings.Join(concatLines(d.lines[:start], moved, d.lines[end:]), "\n"))
+	}
+
+	indent := "  "
+	switch value := d.keys[p].Value.(type) {
+	case *ast.NullNode:
+	case *ast.MappingNode:
+		if value.IsFlowStyle {
+			return fmt.Errorf("%s must be a block mapping to move %s into it", parent, name)
+		}
+		indent = strings.Repeat(" ", value.Values[0].Key.GetToken().Position.Column-d.keys[p].Key.GetToken().Position.Column)
+	case *ast.MappingValueNode:
+		indent = strings.Repeat(" ", value.Key.GetToken().Position.Column-d.keys[p].Key.GetToken().Position.Column)
+	default:
+		return fmt.Errorf("%s must be a mapping to move %s into it", parent, name)
+	}
+
+	_, parentEnd := d.block(p)
+	moved := indentLines(d.lines[start:end], indent)
+	if parentEnd <= start {
+		return d.parse(strings.Join(concatLines(d.lines[:parentEnd], moved, d.lines[parentEnd:start], d.lines[end:]), "\n"))
+	}
+	return d.parse(strings.Join(concatLines(d.lines[:start], d.lines[end:parentEnd], moved, d.lines[parentEnd:]), "\n"))
+}
+
+func indentLines(lines []string, indent string) []string {
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line != "" {
+			line = indent + line
+		}
+		result = append(result, line)
+	}
+	return result
+}
+
+func concatLines(parts ...[]string) []string {
+	var result []string
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate_V1Alpha1(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "context into existing spec",
+			input: `---
+# Compute for production
+apiVersion: pantalon.kallan.dev/v1alpha1 # pinned
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+
+spec:
+    dependsOn:
+      - network-prod   # applied first
+# Passed to the workflow
+context:
+  # The deploying account
+  gcp-service-account: "infrastructure@pantalon-prod.iam.gserviceaccount.com"
+  script: |
+    terraform init
+
+    terraform apply
+# end of file
+`,
+			expected: `---
+# Compute for production
+apiVersion: pantalon.kallan.dev/v1beta1 # pinned
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+
+spec:
+    dependsOn:
+      - network-prod   # applied first
+    # Passed to the workflow
+    context:
+      # The deploying account
+      gcp-service-account: "infrastructure@pantalon-prod.iam.gserviceaccount.com"
+      script: |
+        terraform init
+
+        terraform apply
+# end of file
+`,
+		},
+		{
+			name: "context before spec",
+			input: `apiVersion: "pantalon.kallan.dev/v1alpha1"
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+context:
+  foo: bar
+
+spec:
+  ignore:
+    - "*.md"
+`,
+			expected: `apiVersion: "pantalon.kallan.dev/v1beta1"
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+
+spec:
+  ignore:
+    - "*.md"
+  context:
+    foo: bar
+`,
+		},
+		{
+			name: "spec created for context",
+			input: `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+context: {foo: bar}
+metadata:
+  name: compute-prod
+`,
+			expected: `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+spec:
+  context: {foo: bar}
+metadata:
+  name: compute-prod
+`,
+		},
+		{
+			name: "empty spec",
+			input: `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+context:
+  foo: bar
+`,
+			expected: `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    foo: bar
+`,
+		},
+		{
+			name: "comments before the document",
+			input: `# yaml-language-server: $schema=pantalon.schema.json
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+			expected: `# yaml-language-server: $schema=pantalon.schema.json
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+		},
+		{
+			name: "header comment before context as the first key",
+			input: `# Compute for production
+context:
+  foo: bar
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+			expected: `# Compute for production
+spec:
+  context:
+    foo: bar
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+		},
+		{
+			name: "header comment before context as the first key with spec",
+			input: `---
+# Compute for production
+context:
+  foo: bar
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  ignore:
+    - "*.md"
+`,
+			expected: `---
+# Compute for production
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  ignore:
+    - "*.md"
+  context:
+    foo: bar
+`,
+		},
+		{
+			name: "without context",
+			input: `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+			expected: `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Migrate([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(result))
+
+			_, err = NewStrict().Unmarshal(result)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMigrate_V1Alpha1QuotesScalars(t *testing.T) {
+	yamlDoc := `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+context:
+  count: 1 # replicas
+  enabled: true
+  ratio: 0.5
+  empty:
+  none: null # unset
+  name: compute
+  quoted: 'it''s'
+`
+	expected := `apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  context:
+    count: "1" # replicas
+    enabled: "true"
+    ratio: "0.5"
+    empty: ""
+    none: "" # unset
+    name: compute
+    quoted: 'it''s'
+`
+	result, err := Migrate([]byte(yamlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+
+	cfg, err := NewStrict().Unmarshal(result)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"count": "1", "enabled": "true", "ratio": "0.5", "empty": "", "none": "", "name": "compute", "quoted": "it's"}, cfg.Context)
+}
+
+func TestMigrate_LatestUnchanged(t *testing.T) {
+	result, err := Migrate([]byte(v1beta1YamlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1YamlDoc, string(result))
+}
+
+func TestMigrate_MultipleDocuments(t *testing.T) {
+	yamlDoc := `# One configuration per workspace.
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+context:
+  workspace: eu
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-us
+spec:
+  context:
+    workspace: us
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-ap
+spec:
+  dependsOn:
+    - compute-eu
+# The workspace of the region.
+context:
+  workspace: ap
+`
+	expected := `# One configuration per workspace.
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+spec:
+  context:
+    workspace: eu
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-us
+spec:
+  context:
+    workspace: us
+---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-ap
+spec:
+  dependsOn:
+    - compute-eu
+  # The workspace of the region.
+  context:
+    workspace: ap
+`
+	result, err := Migrate([]byte(yamlDoc))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(result))
+
+	cfgs, err := NewStrict().UnmarshalAll(result)
+	require.NoError(t, err)
+	assert.Len(t, cfgs, 3)
+}
+
+func TestMigrate_MultipleDocumentsInvalid(t *testing.T) {
+	yamlDoc := `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-eu
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-us
+spec: {dependsOn: [compute-eu]}
+context:
+  foo: bar
+`
+	_, err := Migrate([]byte(yamlDoc))
+	assert.EqualError(t, err, "document 1: migrating from pantalon.kallan.dev/v1alpha1 to pantalon.kallan.dev/v1beta1: spec must be a block mapping to move context into it")
+}
+
+func TestMigrate_FlowStyleSpec(t *testing.T) {
+	yamlDoc := `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec: {dependsOn: [network-prod]}
+context:
+  foo: bar
+`
+	_, err := Migrate([]byte(yamlDoc))
+	assert.EqualError(t, err, "migrating from pantalon.kallan.dev/v1alpha1 to pantalon.kallan.dev/v1beta1: spec must be a block mapping to move context into it")
+}
+
+func TestMigrate_InvalidDocument(t *testing.T) {
+	yamlDoc := `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: Compute
+`
+	_, err := Migrate([]byte(yamlDoc))
+	assert.EqualError(t, err, "invalid metadata.name")
+}
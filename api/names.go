@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrDuplicateName = errors.New("duplicate metadata.name")
+	ErrNamePolicy    = errors.New("metadata.name does not match name pattern")
+)
+
+var placeholderRegexp = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// CheckUniqueNames returns an error for every metadata.name used by more than one configuration, listing the path
+// of each.
+func CheckUniqueNames(items []ConfigurationItem) error {
+	var names []string
+	paths := make(map[string][]string, len(items))
+	for _, item := range items {
+		if _, ok := paths[item.Name]; !ok {
+			names = append(names, item.Name)
+		}
+		paths[item.Name] = append(paths[item.Name], item.Path)
+	}
+
+	var errs []error
+	for _, name := range names {
+		if len(paths[name]) > 1 {
+			errs = append(errs, fmt.Errorf("%w %q: %s", ErrDuplicateName, name, strings.Join(paths[name], ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NamePolicy requires the metadata.name of each configuration to be derived from its directory.
+//
+// The directory pattern matches a configuration directory, capturing a single path segment, or part of one, for each
+// `{placeholder}`. A `*` matches a segment without capturing it. The name pattern is then expanded with the captured
+// values, e.g. `terraform/{component}/environments/{env}` and `{component}-{env}` expects `compute-prod` for
+// `terraform/compute/environments/prod`. Configurations in directories which don't match are not checked.
+type NamePolicy struct {
+	dir  *regexp.Regexp
+	name string
+}
+
+// NewNamePolicy returns a NamePolicy, checking every placeholder in name is captured by dir.
+func NewNamePolicy(dir, name string) (NamePolicy, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	captured := make(map[string]bool)
+	last := 0
+	for _, m := range placeholderRegexp.FindAllStringSubmatchIndex(dir, -1) {
+		expr.WriteString(dirLiteral(dir[last:m[0]]))
+		placeholder := dir[m[2]:m[3]]
+		if captured[placeholder] {
+			return NamePolicy{}, fmt.Errorf("invalid directory pattern %q: duplicate placeholder {%s}", dir, placeholder)
+		}
+		captured[placeholder] = true
+		fmt.Fprintf(&expr, "(?P<%s>[^/]+)", placeholder)
+		last = m[1]
+	}
+	expr.WriteString(dirLiteral(dir[last:]))
+	expr.WriteString("$")
+
+	for _, m := range placeholderRegexp.FindAllStringSubmatch(name, -1) {
+		if !captured[m[1]] {
+			return NamePolicy{}, fmt.Errorf("invalid name pattern %q: placeholder {%s} is not in directory pattern %q", name, m[1], dir)
+		}
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return NamePolicy{}, fmt.Errorf("invalid directory pattern %q: %w", dir, err)
+	}
+	return NamePolicy{dir: re, name: name}, nil
+}
+
+// dirLiteral quotes s for a regular expression, with `*` matching a single path segment.
+func dirLiteral(s string) string {
+	parts := strings.Split(s, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, "[^/]+")
+}
+
+// Expected returns the name expected for a configuration in dir, or false if dir doesn't match the policy.
+func (p NamePolicy) Expected(dir string) (string, bool) {
+	m := p.dir.FindStringSubmatch(dir)
+	if m == nil {
+		return "", false
+	}
+
+	values := make(map[string]string, len(m))
+	for i, placeholder := range p.dir.SubexpNames() {
+		if placeholder != "" {
+			values[placeholder] = m[i]
+		}
+	}
+
+	name := placeholderRegexp.ReplaceAllStringFunc(p.name, func(s string) string {
+		return values[s[1:len(s)-1]]
+	})
+	return strings.ToLower(name), true
+}
+
+// Check returns an error for every configuration whose metadata.name doesn't match the name expected for its
+// directory.
+func (p NamePolicy) Check(items []ConfigurationItem) error {
+	var errs []error
+	for _, item := range items {
+		expected, ok := p.Expected(item.Dir)
+		if !ok || item.Name == expected {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %w %q: expected %q, got %q", item.Path, ErrNamePolicy, p.name, expected, item.Name))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUniqueNames(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "network-prod", Path: "network/a/pantalon.yaml"},
+		{Name: "compute-prod", Path: "compute/pantalon.yaml"},
+		{Name: "network-prod", Path: "network/b/pantalon.yaml"},
+		{Name: "dns", Path: "dns/a/pantalon.yaml"},
+		{Name: "network-prod", Path: "network/c/pantalon.yaml"},
+		{Name: "dns", Path: "dns/b/pantalon.yaml"},
+	}
+
+	err := CheckUniqueNames(items)
+	assert.ErrorIs(t, err, ErrDuplicateName)
+	assert.EqualError(t, err, `duplicate metadata.name "network-prod": network/a/pantalon.yaml, network/b/pantalon.yaml, network/c/pantalon.yaml
+duplicate metadata.name "dns": dns/a/pantalon.yaml, dns/b/pantalon.yaml`)
+}
+
+func TestCheckUniqueNames_Unique(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "network-prod", Path: "network/pantalon.yaml"},
+		{Name: "compute-prod", Path: "compute/pantalon.yaml"},
+	}
+
+	assert.NoError(t, CheckUniqueNames(items))
+}
+
+func TestNamePolicy_Expected(t *testing.T) {
+	policy, err := NewNamePolicy("terraform/{component}/environments/{env}", "{component}-{env}")
+	require.NoError(t, err)
+
+	tests := []struct {
+		dir      string
+		expected string
+		ok       bool
+	}{
+		{"terraform/compute/environments/prod", "compute-prod", true},
+		{"terraform/Network/environments/dev", "network-dev", true},
+		{"terraform/compute/environments", "", false},
+		{"terraform/compute/environments/prod/eu", "", false},
+		{"other/compute/environments/prod", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			expected, ok := policy.Expected(tt.dir)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, expected)
+		})
+	}
+}
+
+func TestNamePolicy_PartialSegmentAndWildcard(t *testing.T) {
+	policy, err := NewNamePolicy("*/stack-{stack}/{region}", "{stack}-{region}")
+	require.NoError(t, err)
+
+	expected, ok := policy.Expected("aws/stack-web/ap-southeast-2")
+	assert.True(t, ok)
+	assert.Equal(t, "web-ap-southeast-2", expected)
+
+	_, ok = policy.Expected("aws/gcp/stack-web/ap-southeast-2")
+	assert.False(t, ok)
+}
+
+func TestNamePolicy_Check(t *testing.T) {
+	policy, err := NewNamePolicy("terraform/{component}/environments/{env}", "{component}-{env}")
+	require.NoError(t, err)
+
+	items := []ConfigurationItem{
+		{Name: "compute-prod", Path: "terraform/compute/environments/prod/pantalon.yaml", Dir: "terraform/compute/environments/prod"},
+		{Name: "prod-network", Path: "terraform/network/environments/prod/pantalon.yaml", Dir: "terraform/network/environments/prod"},
+		{Name: "dns", Path: "terraform/dns/pantalon.yaml", Dir: "terraform/dns"},
+	}
+
+	err = policy.Check(items)
+	assert.ErrorIs(t, err, ErrNamePolicy)
+	assert.EqualError(t, err, `terraform/network/environments/prod/pantalon.yaml: metadata.name does not match name pattern "{component}-{env}": expected "network-prod", got "prod-network"`)
+}
+
+func TestNewNamePolicy_Invalid(t *testing.T) {
+	_, err := NewNamePolicy("terraform/{component}", "{component}-{env}")
+	assert.EqualError(t, err, `invalid name pattern "{component}-{env}": placeholder {env} is not in directory pattern "terraform/{component}"`)
+
+	_, err = NewNamePolicy("{env}/{env}", "{env}")
+	assert.EqualError(t, err, `invalid directory pattern "{env}/{env}": duplicate placeholder {env}`)
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNestedModule = errors.New("local module contains nested configuration")
+
+// CheckNesting returns an error for every configuration consuming a local module which is, or contains, the
+// directory of a configuration nested within its own. The files of the nested configuration would otherwise belong to
+// both.
+func CheckNesting(items []ConfigurationItem) error {
+	var errs []error
+	for _, parent := range items {
+		for _, module := range parent.Modules {
+			for _, child := range items {
+				if child.Dir == parent.Dir || !isWithinDir(child.Dir, parent.Dir) || !isWithinDir(child.Dir, module) {
+					continue
+				}
+				errs = append(errs, fmt.Errorf("%s: %w %q: %s", parent.Path, ErrNestedModule, child.Name, module))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isWithinDir reports whether dir is parent or within it, comparing whole path segments.
+func isWithinDir(dir, parent string) bool {
+	dir = path.Clean(filepath.ToSlash(dir))
+	parent = path.Clean(filepath.ToSlash(parent))
+	return parent == "." || dir == parent || strings.HasPrefix(dir, parent+"/")
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckNesting(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "parent", Path: "a/pantalon.yaml", Dir: "a", Modules: []string{"a/child", "a/modules/x", "shared"}},
+		{Name: "child", Path: "a/child/pantalon.yaml", Dir: "a/child"},
+		{Name: "stacks", Path: "b/pantalon.yaml", Dir: "b", Modules: []string{"b/stacks"}},
+		{Name: "stack", Path: "b/stacks/one/pantalon.yaml", Dir: "b/stacks/one"},
+		{Name: "sibling", Path: "shared/pantalon.yaml", Dir: "shared"},
+	}
+
+	err := CheckNesting(items)
+	assert.ErrorIs(t, err, ErrNestedModule)
+	assert.EqualError(t, err, `a/pantalon.yaml: local module contains nested configuration "child": a/child
+b/pantalon.yaml: local module contains nested configuration "stack": b/stacks`)
+}
+
+func TestCheckNesting_ModulesOutsideNestedConfigurations(t *testing.T) {
+	items := []ConfigurationItem{
+		{Name: "parent", Path: "a/pantalon.yaml", Dir: "a", Modules: []string{"a/modules/x", "modules/y"}},
+		{Name: "child", Path: "a/child/pantalon.yaml", Dir: "a/child", Modules: []string{"a/modules/x"}},
+	}
+
+	assert.NoError(t, CheckNesting(items))
+}
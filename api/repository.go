@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/parser"
+)
+
+const RepositoryKind = "RepositoryConfiguration"
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// repositoryVersions are the apiVersions of a .pantalon.yaml, which change independently of those of pantalon.yaml
+// files.
+var repositoryVersions = []string{V1Beta1}
+
+// RepositoryConfiguration is the .pantalon.yaml at the root of a repository, holding the defaults of every command
+// run within it.
+type RepositoryConfiguration struct {
+	ApiVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Spec       RepositorySpec `yaml:"spec,omitempty"`
+	// Dir is the directory containing the file, which paths in Spec are relative to.
+	Dir string `yaml:"-"`
+	// Path is the path the file was read from.
+	Path string `yaml:"-"`
+}
+
+type RepositorySpec struct {
+	// Roots lists the directories searched for pantalon.yaml files. The whole repository is searched if empty.
+	Roots []string `yaml:"roots,omitempty"`
+	// Exclude lists doublestar patterns of directories which are not searched.
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Nested searches within the directory of each pantalon.yaml for nested configurations.
+	Nested bool `yaml:"nested,omitempty"`
+	// OutputFormat is the default --output-format, json or yaml.
+	OutputFormat string `yaml:"outputFormat,omitempty"`
+	// Context is merged into the context of every configuration, which takes precedence.
+	Context map[string]any `yaml:"context,omitempty"`
+	// Env lists the environment variables which context values may reference as `${env.NAME}`.
+	Env []string `yaml:"env,omitempty"`
+	// ContextSchema declares the expected type of context keys, checked after defaults are merged.
+	ContextSchema map[string]ContextKeySchema `yaml:"contextSchema,omitempty"`
+	// NamePolicy is the default --name-dir-pattern and --name-pattern of `pantalon validate`.
+	NamePolicy *NamePolicySpec `yaml:"namePolicy,omitempty"`
+	// Presets are named sets of filters used by `pantalon list --preset`.
+	Presets map[string]FilterPreset `yaml:"presets,omitempty"`
+}
+
+type NamePolicySpec struct {
+	Dir  string `yaml:"dir"`
+	Name string `yaml:"name"`
+}
+
+// FilterPreset holds the defaults of the filter flags of `pantalon list`. Flags given on the command line take
+// precedence.
+type FilterPreset struct {
+	PathGlobs        []string `yaml:"pathGlobs,omitempty"`
+	Selectors        []string `yaml:"selectors,omitempty"`
+	ChangedDirsMatch string   `yaml:"changedDirsMatch,omitempty"`
+}
+
+// UnmarshalRepository decodes a .pantalon.yaml document, rejecting unknown fields.
+func UnmarshalRepository(yamlDoc []byte) (RepositoryConfiguration, error) {
+	cfg := RepositoryConfiguration{}
+
+	file, err := parser.ParseBytes(yamlDoc, 0)
+	if err != nil {
+		return cfg, decodeError(err)
+	}
+
+	err = yaml.Unmarshal(yamlDoc, &cfg)
+	if err != nil {
+		return cfg, decodeError(err)
+	}
+
+	errs := strictCheck(file, reflect.TypeOf(cfg))
+	errs = append(errs, validateRepository(cfg)...)
+	if len(errs) > 0 {
+		locate(file, errs)
+		return cfg, joinValidationErrors(errs)
+	}
+	return cfg, nil
+}
+
+// validateRepository returns an error for every invalid field of cfg.
+func validateRepository(cfg RepositoryConfiguration) []*ValidationError {
+	var errs []*ValidationError
+
+	if !slices.Contains(repositoryVersions, cfg.ApiVersion) {
+		errs = append(errs, newValidationError("invalid version", "apiVersion"))
+	}
+
+	if cfg.Kind != RepositoryKind {
+		errs = append(errs, newValidationError("invalid kind", "kind"))
+	}
+
+	for i, root := range cfg.Spec.Roots {
+		if !isRelativePath(root) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.roots %q: must be a directory within the repository", root), "spec", "roots", i))
+		}
+	}
+
+	for i, pattern := range cfg.Spec.Exclude {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.exclude %q", pattern), "spec", "exclude", i))
+		}
+	}
+
+	switch cfg.Spec.OutputFormat {
+	case "", "json", "yaml":
+	default:
+		errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.outputFormat %q: must be json or yaml", cfg.Spec.OutputFormat), "spec", "outputFormat"))
+	}
+
+	if p := cfg.Spec.NamePolicy; p != nil {
+		if _, err := NewNamePolicy(p.Dir, p.Name); err != nil {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.namePolicy: %v", err), "spec", "namePolicy"))
+		}
+	}
+
+	for i, name := range cfg.Spec.Env {
+		if !envNameRegexp.MatchString(name) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.env %q", name), "spec", "env", i))
+		}
+	}
+
+	keys := make([]string, 0, len(cfg.Spec.ContextSchema))
+	for key := range cfg.Spec.ContextSchema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		errs = append(errs, cfg.Spec.ContextSchema[key].validate(key)...)
+	}
+
+	names := make([]string, 0, len(cfg.Spec.Presets))
+	for name := range cfg.Spec.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		preset := cfg.Spec.Presets[name]
+		for i, pattern := range preset.PathGlobs {
+			if !doublestar.ValidatePattern(pattern) {
+				errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.presets.%s.pathGlobs %q", name, pattern), "spec", "presets", name, "pathGlobs", i))
+			}
+		}
+		for i, selector := range preset.Selectors {
+			if _, err := ParseSelector(selector); err != nil {
+				errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.presets.%s.selectors %q: %v", name, selector, err), "spec", "presets", name, "selectors", i))
+			}
+		}
+	}
+	return errs
+}
+
+// isRelativePath reports whether p is a relative path which doesn't leave the directory it's relative to.
+func isRelativePath(p string) bool {
+	if p == "" || path.IsAbs(p) {
+		return false
+	}
+	clean := path.Clean(p)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+// NamePolicy returns the name policy of the repository, or nil if it has none.
+func (r RepositoryConfiguration) NamePolicy() (*NamePolicy, error) {
+	if r.Spec.NamePolicy == nil {
+		return nil, nil
+	}
+	p, err := NewNamePolicy(r.Spec.NamePolicy.Dir, r.Spec.NamePolicy.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ContextDefaults returns the context of r as the outermost context defaults, inherited by every configuration and
+// recorded as coming from r.Path.
+func (r RepositoryConfiguration) ContextDefaults() []ContextDefaults {
+	if len(r.Spec.Context) == 0 {
+		return nil
+	}
+	return []ContextDefaults{{
+		ApiVersion: r.ApiVersion,
+		Kind:       ContextDefaultsKind,
+		Spec:       ContextDefaultsSpec{Context: r.Spec.Context},
+		Path:       r.Path,
+	}}
+}
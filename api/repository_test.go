@@ -0,0 +1,172 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalRepository(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: RepositoryConfiguration
+spec:
+  roots:
+    - terraform
+  exclude:
+    - "**/archived"
+  outputFormat: yaml
+  context:
+    region: "us-east-1"
+  namePolicy:
+    dir: terraform/{component}
+    name: "{component}"
+  presets:
+    prod:
+      selectors:
+        - tier=prod
+      pathGlobs:
+        - "terraform/**"
+      changedDirsMatch: exact
+`
+	cfg, err := UnmarshalRepository([]byte(yamlDoc))
+	require.NoError(t, err)
+
+	assert.Equal(t, RepositorySpec{
+		Roots:        []string{"terraform"},
+		Exclude:      []string{"**/archived"},
+		OutputFormat: "yaml",
+		Context:      map[string]any{"region": "us-east-1"},
+		NamePolicy:   &NamePolicySpec{Dir: "terraform/{component}", Name: "{component}"},
+		Presets: map[string]FilterPreset{
+			"prod": {Selectors: []string{"tier=prod"}, PathGlobs: []string{"terraform/**"}, ChangedDirsMatch: "exact"},
+		},
+	}, cfg.Spec)
+
+	policy, err := cfg.NamePolicy()
+	require.NoError(t, err)
+	expected, ok := policy.Expected("terraform/compute")
+	assert.True(t, ok)
+	assert.Equal(t, "compute", expected)
+}
+
+func TestUnmarshalRepository_AllErrorsWithPositions(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+spec:
+  roots:
+    - ../other
+  outputFormat: xml
+  namePolicy:
+    dir: terraform/{component}
+    name: "{env}"
+  presets:
+    prod:
+      selectors:
+        - "tier in prod"
+  exlude:
+    - archived
+`
+	_, err := UnmarshalRepository([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	require.Len(t, messages, 7)
+	assert.Equal(t, "unknown field spec.exlude, did you mean spec.exclude?", messages[0])
+	assert.Equal(t, "invalid version", messages[1])
+	assert.Equal(t, "invalid kind", messages[2])
+	assert.Equal(t, `invalid spec.roots "../other": must be a directory within the repository`, messages[3])
+	assert.Equal(t, `invalid spec.outputFormat "xml": must be json or yaml`, messages[4])
+	assert.Contains(t, messages[5], "invalid spec.namePolicy: invalid name pattern")
+	assert.Contains(t, messages[6], `invalid spec.presets.prod.selectors "tier in prod"`)
+
+	assert.Equal(t, 6, errs[3].Line)
+	assert.Equal(t, 14, errs[6].Line)
+}
+
+func TestIsRelativePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"terraform", true},
+		{"terraform/../modules", true},
+		{".", true},
+		{"", false},
+		{"/terraform", false},
+		{"..", false},
+		{"../terraform", false},
+		{"terraform/../..", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isRelativePath(tt.path))
+		})
+	}
+}
+
+func TestRepositoryConfiguration_ContextDefaults(t *testing.T) {
+	cfg := RepositoryConfiguration{
+		ApiVersion: V1Beta1,
+		Kind:       RepositoryKind,
+		Spec:       RepositorySpec{Context: map[string]any{"team": "platform"}},
+		Path:       "infra/.pantalon.yaml",
+	}
+
+	assert.Equal(t, []ContextDefaults{{
+		ApiVersion: V1Beta1,
+		Kind:       ContextDefaultsKind,
+		Spec:       ContextDefaultsSpec{Context: map[string]any{"team": "platform"}},
+		Path:       "infra/.pantalon.yaml",
+	}}, cfg.ContextDefaults())
+	assert.Nil(t, RepositoryConfiguration{}.ContextDefaults())
+}
+
+func TestUnmarshalRepository_ContextSchema(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: RepositoryConfiguration
+spec:
+  contextSchema:
+    regions:
+      type: array
+      items: string
+      required: true
+    runner:
+      type: map
+    region:
+      type: string
+      items: string
+`
+	_, err := UnmarshalRepository([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, `invalid spec.contextSchema.region.items "string": must be a type of the items of an array`, errs[0].Message)
+	assert.Equal(t, 14, errs[0].Line)
+	assert.Equal(t, `invalid spec.contextSchema.runner.type "map"`, errs[1].Message)
+	assert.Equal(t, 11, errs[1].Line)
+}
+
+func TestUnmarshalRepository_Env(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: RepositoryConfiguration
+spec:
+  env:
+    - GITHUB_REF_NAME
+    - 1PASSWORD
+`
+	cfg, err := UnmarshalRepository([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, `invalid spec.env "1PASSWORD"`, errs[0].Message)
+	assert.Equal(t, 7, errs[0].Line)
+	assert.Equal(t, []string{"GITHUB_REF_NAME", "1PASSWORD"}, cfg.Spec.Env)
+}
@@ -0,0 +1,336 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaKeywords are added to the generated schema of each field, for the rules which can't be derived from its type.
+// Rules which can't be expressed at all, such as the length of a label key prefix, are only checked by Unmarshal.
+var schemaKeywords = map[string]yaml.MapSlice{
+	"apiVersion": {
+		{Key: "description", Value: "The version of the pantalon.yaml schema."},
+	},
+	"kind": {
+		{Key: "const", Value: TerraformKind},
+	},
+	"metadata.name": {
+		{Key: "description", Value: "Identifies the configuration. Must be unique within the repository and a lowercase DNS label."},
+		{Key: "pattern", Value: "^" + subdomainLabelPattern + "$"},
+		{Key: "maxLength", Value: 253},
+	},
+	"metadata.labels": {
+		{Key: "description", Value: "Kubernetes style labels, used with --selector."},
+		{Key: "propertyNames", Value: yaml.MapSlice{
+			{Key: "pattern", Value: "^(" + subdomainLabelPattern + `(\.` + subdomainLabelPattern + ")*/)?" + labelNamePattern + "$"},
+		}},
+		{Key: "additionalProperties", Value: yaml.MapSlice{
+			{Key: "type", Value: "string"},
+			{Key: "pattern", Value: "^(" + labelNamePattern + ")?$"},
+			{Key: "maxLength", Value: 63},
+		}},
+	},
+	"spec.dependsOn": {
+		{Key: "description", Value: "The metadata.name of configurations which must be applied before this one."},
+	},
+	"spec.dependsOn[]": {
+		{Key: "pattern", Value: "^" + subdomainLabelPattern + "$"},
+	},
+	"spec.ignore": {
+		{Key: "description", Value: "Doublestar patterns of changed files, relative to the configuration directory, which don't change the configuration."},
+	},
+	"spec.matrix": {
+		{Key: "description", Value: "Expands the configuration into an item for each combination of the values of its keys, like the matrix of a GitHub Actions job."},
+		{Key: "properties", Value: yaml.MapSlice{
+			{Key: matrixInclude, Value: matrixEntriesSchema},
+			{Key: matrixExclude, Value: matrixEntriesSchema},
+		}},
+		{Key: "additionalProperties", Value: yaml.MapSlice{
+			{Key: "type", Value: "array"},
+			{Key: "items", Value: yaml.MapSlice{{Key: "type", Value: []string{"string", "number", "boolean"}}}},
+			{Key: "minItems", Value: 1},
+		}},
+	},
+	"context": {
+		{Key: "description", Value: "String values passed through to the output, such as a service account. Only read by apiVersion " + V1Alpha1 + ", later versions use spec.context."},
+	},
+	"spec.context": {
+		{Key: "description", Value: "Values of any type passed through to the output, such as a service account. Read from apiVersion " + V1Beta1 + ", replacing the top-level context of " + V1Alpha1 + "."},
+	},
+}
+
+var matrixEntriesSchema = yaml.MapSlice{
+	{Key: "type", Value: "array"},
+	{Key: "items", Value: yaml.MapSlice{{Key: "type", Value: "object"}}},
+}
+
+// Schema returns a JSON Schema for pantalon.yaml files of every apiVersion, each checked against the schema of its
+// own apiVersion.
+func Schema() yaml.MapSlice {
+	versions := ApiVersions()
+	conditions := make([]yaml.MapSlice, 0, len(versions))
+	for _, version := range versions {
+		schema, _ := versionSchema(version)
+		conditions = append(conditions, yaml.MapSlice{
+			{Key: "if", Value: yaml.MapSlice{
+				{Key: "properties", Value: yaml.MapSlice{
+					{Key: "apiVersion", Value: yaml.MapSlice{{Key: "const", Value: version}}},
+				}},
+				{Key: "required", Value: []string{"apiVersion"}},
+			}},
+			{Key: "then", Value: schema},
+		})
+	}
+
+	return yaml.MapSlice{
+		{Key: "$schema", Value: jsonSchemaDraft},
+		{Key: "title", Value: "pantalon.yaml"},
+		{Key: "type", Value: "object"},
+		{Key: "properties", Value: yaml.MapSlice{
+			{Key: "apiVersion", Value: append(schemaKeywords["apiVersion"], yaml.MapItem{Key: "enum", Value: versions})},
+		}},
+		{Key: "required", Value: []string{"apiVersion"}},
+		{Key: "allOf", Value: conditions},
+	}
+}
+
+// SchemaFor returns a JSON Schema for pantalon.yaml files of the apiVersion version, generated from its yaml tags.
+//
+// Unknown fields are rejected, as in strict mode. Fields without omitempty are required.
+func SchemaFor(version string) (yaml.MapSlice, error) {
+	body, err := versionSchema(version)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := yaml.MapSlice{
+		{Key: "$schema", Value: jsonSchemaDraft},
+		{Key: "title", Value: "pantalon.yaml " + version},
+	}
+	return append(schema, body...), nil
+}
+
+// versionSchema returns the schema of the document of the apiVersion version, without the keywords of a root schema.
+func versionSchema(version string) (yaml.MapSlice, error) {
+	t, ok := configurationType(version)
+	if !ok {
+		return nil, fmt.Errorf("unsupported apiVersion %q", version)
+	}
+
+	keywords := make(map[string]yaml.MapSlice, len(schemaKeywords)+1)
+	for path, k := range schemaKeywords {
+		keywords[path] = k
+	}
+	keywords["apiVersion"] = append(keywords["apiVersion"], yaml.MapItem{Key: "const", Value: version})
+
+	return typeSchema(t, "", keywords), nil
+}
+
+func typeSchema(t reflect.Type, path string, keywords map[string]yaml.MapSlice) yaml.MapSlice {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var schema yaml.MapSlice
+	switch {
+	case t == reflect.TypeOf(yaml.MapSlice{}):
+		// An ordered mapping, with any keys.
+		schema = yaml.MapSlice{{Key: "type", Value: "object"}}
+	case t.Kind() == reflect.Struct:
+		var properties yaml.MapSlice
+		required := make([]string, 0)
+		for i := 0; i < t.NumField(); i++ {
+			name, omitEmpty, ok := yamlField(t.Field(i))
+			if !ok {
+				continue
+			}
+			properties = append(properties, yaml.MapItem{Key: name, Value: typeSchema(t.Field(i).Type, schemaPath(path, name), keywords)})
+			if !omitEmpty {
+				required = append(required, name)
+			}
+		}
+		schema = yaml.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "properties", Value: properties},
+		}
+		if len(required) > 0 {
+			schema = append(schema, yaml.MapItem{Key: "required", Value: required})
+		}
+		schema = append(schema, yaml.MapItem{Key: "additionalProperties", Value: false})
+	case t.Kind() == reflect.Map:
+		schema = yaml.MapSlice{
+			{Key: "type", Value: "object"},
+			{Key: "additionalProperties", Value: typeSchema(t.Elem(), path+"[]", keywords)},
+		}
+	case t.Kind() == reflect.Slice:
+		schema = yaml.MapSlice{
+			{Key: "type", Value: "array"},
+			{Key: "items", Value: typeSchema(t.Elem(), path+"[]", keywords)},
+		}
+	case t.Kind() == reflect.String:
+		schema = yaml.MapSlice{{Key: "type", Value: "string"}}
+	case t.Kind() == reflect.Interface:
+		// Any value is allowed.
+		schema = yaml.MapSlice{}
+	}
+
+	return mergeKeywords(schema, keywords[path])
+}
+
+func schemaPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// mergeKeywords adds each keyword to schema, replacing an existing keyword of the same name.
+func mergeKeywords(schema, keywords yaml.MapSlice) yaml.MapSlice {
+	for _, keyword := range keywords {
+		replaced := false
+		for i := range schema {
+			if schema[i].Key == keyword.Key {
+				schema[i].Value = keyword.Value
+				replaced = true
+			}
+		}
+		if !replaced {
+			schema = append(schema, keyword)
+		}
+	}
+	return schema
+}
+
+// generatedSchema is the Schema which documents are checked against in strict mode.
+var generatedSchema = sync.OnceValue(Schema)
+
+// validateSchema returns an error for every keyword of schema which value, a decoded YAML document or a value within
+// one at segments, doesn't satisfy. Only the keywords the generated schema uses are supported.
+//
+// A null value is treated as missing, as it is when decoding.
+func validateSchema(schema yaml.MapSlice, value any, segments []any) []*ValidationError {
+	field := fieldName(segments)
+	invalid := func(format string, args ...any) []*ValidationError {
+		return []*ValidationError{newValidationError(fmt.Sprintf("invalid %s: ", field)+fmt.Sprintf(format, args...), segments...)}
+	}
+
+	if t, ok := schemaKeyword(schema, "type"); ok {
+		types, ok := t.([]string)
+		if !ok {
+			types = []string{t.(string)}
+		}
+		if !slices.ContainsFunc(types, func(t string) bool { return hasContextType(value, t) }) {
+			return invalid("expected %s, got %s", strings.Join(types, " or "), contextType(value))
+		}
+	}
+	if c, ok := schemaKeyword(schema, "const"); ok && c != value {
+		return invalid("expected %v", c)
+	}
+	if e, ok := schemaKeyword(schema, "enum"); ok && !slices.Contains(e.([]string), fmt.Sprint(value)) {
+		return invalid("expected one of %s", strings.Join(e.([]string), ", "))
+	}
+
+	var errs []*ValidationError
+	switch value := value.(type) {
+	case string:
+		if p, ok := schemaKeyword(schema, "pattern"); ok && !regexp.MustCompile(p.(string)).MatchString(value) {
+			errs = append(errs, invalid("%q doesn't match %s", value, p)...)
+		}
+		if n, ok := schemaKeyword(schema, "maxLength"); ok && len(value) > n.(int) {
+			errs = append(errs, invalid("longer than %d characters", n)...)
+		}
+	case []any:
+		if n, ok := schemaKeyword(schema, "minItems"); ok && len(value) < n.(int) {
+			errs = append(errs, invalid("fewer than %d items", n)...)
+		}
+		if items, ok := schemaKeyword(schema, "items"); ok {
+			for i, item := range value {
+				errs = append(errs, validateSchema(items.(yaml.MapSlice), item, appendSegment(segments, i))...)
+			}
+		}
+	case map[string]any:
+		errs = append(errs, validateProperties(schema, value, segments)...)
+	}
+
+	if conditions, ok := schemaKeyword(schema, "allOf"); ok {
+		for _, condition := range conditions.([]yaml.MapSlice) {
+			test, _ := schemaKeyword(condition, "if")
+			then, _ := schemaKeyword(condition, "then")
+			if len(validateSchema(test.(yaml.MapSlice), value, segments)) == 0 {
+				errs = append(errs, validateSchema(then.(yaml.MapSlice), value, segments)...)
+			}
+		}
+	}
+	return errs
+}
+
+// validateProperties returns an error for every property of value which doesn't satisfy schema, and for every missing
+// required property.
+func validateProperties(schema yaml.MapSlice, value map[string]any, segments []any) []*ValidationError {
+	var errs []*ValidationError
+	if required, ok := schemaKeyword(schema, "required"); ok {
+		for _, key := range required.([]string) {
+			if value[key] == nil {
+				field := appendSegment(segments, key)
+				errs = append(errs, newValidationError(fmt.Sprintf("missing %s", fieldName(field)), field...))
+			}
+		}
+	}
+
+	properties, _ := schemaKeyword(schema, "properties")
+	additional, hasAdditional := schemaKeyword(schema, "additionalProperties")
+	names, hasNames := schemaKeyword(schema, "propertyNames")
+
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		field := appendSegment(segments, key)
+		if hasNames {
+			errs = append(errs, validateSchema(names.(yaml.MapSlice), key, field)...)
+		}
+		if value[key] == nil {
+			continue
+		}
+		if properties, ok := properties.(yaml.MapSlice); ok {
+			if property, ok := schemaKeyword(properties, key); ok {
+				errs = append(errs, validateSchema(property.(yaml.MapSlice), value[key], field)...)
+				continue
+			}
+		}
+		if !hasAdditional {
+			continue
+		}
+		switch additional := additional.(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, newValidationError(fmt.Sprintf("unknown field %s", fieldName(field)), field...))
+			}
+		case yaml.MapSlice:
+			errs = append(errs, validateSchema(additional, value[key], field)...)
+		}
+	}
+	return errs
+}
+
+// schemaKeyword returns the value of the keyword name of schema.
+func schemaKeyword(schema yaml.MapSlice, name string) (any, bool) {
+	for _, item := range schema {
+		if item.Key == name {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
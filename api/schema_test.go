@@ -0,0 +1,206 @@
+package api
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// schemaValue returns the value at keys within schema.
+func schemaValue(t require.TestingT, schema yaml.MapSlice, keys ...string) any {
+	var value any = schema
+	for _, key := range keys {
+		m, ok := value.(yaml.MapSlice)
+		require.True(t, ok, "%v is not a mapping", key)
+		value = nil
+		for _, item := range m {
+			if item.Key == key {
+				value = item.Value
+			}
+		}
+		require.NotNil(t, value, "missing %v", key)
+	}
+	return value
+}
+
+func TestSchema_Structure(t *testing.T) {
+	schema, err := SchemaFor(LatestVersion)
+	require.NoError(t, err)
+
+	assert.Equal(t, jsonSchemaDraft, schemaValue(t, schema, "$schema"))
+	assert.Equal(t, []string{"apiVersion", "kind", "metadata"}, schemaValue(t, schema, "required"))
+	assert.Equal(t, false, schemaValue(t, schema, "additionalProperties"))
+	assert.Equal(t, LatestVersion, schemaValue(t, schema, "properties", "apiVersion", "const"))
+	assert.Equal(t, []string{"name"}, schemaValue(t, schema, "properties", "metadata", "required"))
+	assert.Equal(t, "array", schemaValue(t, schema, "properties", "spec", "properties", "ignore", "type"))
+	assert.Equal(t, yaml.MapSlice{}, schemaValue(t, schema, "properties", "spec", "properties", "context", "additionalProperties"))
+
+	var properties []any
+	for _, item := range schemaValue(t, schema, "properties").(yaml.MapSlice) {
+		properties = append(properties, item.Key)
+	}
+	assert.Equal(t, []any{"apiVersion", "kind", "metadata", "spec"}, properties)
+}
+
+func TestSchemaFor_V1Alpha1(t *testing.T) {
+	schema, err := SchemaFor(V1Alpha1)
+	require.NoError(t, err)
+
+	assert.Equal(t, V1Alpha1, schemaValue(t, schema, "properties", "apiVersion", "const"))
+	assert.Equal(t, "string", schemaValue(t, schema, "properties", "context", "additionalProperties", "type"))
+
+	_, err = SchemaFor("pantalon.kallan.dev/v1")
+	assert.EqualError(t, err, `unsupported apiVersion "pantalon.kallan.dev/v1"`)
+}
+
+func TestSchema_EveryVersion(t *testing.T) {
+	schema := Schema()
+
+	assert.Equal(t, ApiVersions(), schemaValue(t, schema, "properties", "apiVersion", "enum"))
+	conditions := schemaValue(t, schema, "allOf").([]yaml.MapSlice)
+	require.Len(t, conditions, len(ApiVersions()))
+	for i, version := range ApiVersions() {
+		assert.Equal(t, version, schemaValue(t, conditions[i], "if", "properties", "apiVersion", "const"))
+		assert.Equal(t, version, schemaValue(t, conditions[i], "then", "properties", "apiVersion", "const"))
+	}
+
+	alpha := schemaValue(t, conditions[0], "then", "properties", "context", "description")
+	beta := schemaValue(t, conditions[1], "then", "properties", "spec", "properties", "context", "description")
+	assert.Contains(t, alpha, V1Alpha1)
+	assert.NotEqual(t, alpha, beta)
+}
+
+func TestSchema_JSON(t *testing.T) {
+	b, err := yaml.MarshalWithOptions(Schema(), yaml.JSON())
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"$schema": "https://json-schema.org/draft/2020-12/schema"`)
+}
+
+// The schema patterns must agree with the validation in Unmarshal, within the lengths the schema can't express.
+func TestSchema_PatternsMatchValidation(t *testing.T) {
+	schema, err := SchemaFor(LatestVersion)
+	require.NoError(t, err)
+	metadata := []string{"properties", "metadata", "properties"}
+	namePattern := regexp.MustCompile(schemaValue(t, schema, append(metadata, "name", "pattern")...).(string))
+	keyPattern := regexp.MustCompile(schemaValue(t, schema, append(metadata, "labels", "propertyNames", "pattern")...).(string))
+	valuePattern := regexp.MustCompile(schemaValue(t, schema, append(metadata, "labels", "additionalProperties", "pattern")...).(string))
+	dependsOnPattern := regexp.MustCompile(schemaValue(t, schema, "properties", "spec", "properties", "dependsOn", "items", "pattern").(string))
+
+	rapid.Check(t, func(t *rapid.T) {
+		s := rapid.StringMatching(`[-a-zA-Z0-9_./]{0,40}`).Draw(t, "s")
+
+		assert.Equal(t, isValidSubdomainLabel(s), namePattern.MatchString(s), "metadata.name %q", s)
+		assert.Equal(t, isValidSubdomainLabel(s), dependsOnPattern.MatchString(s), "spec.dependsOn %q", s)
+		assert.Equal(t, isValidLabelKey(s), keyPattern.MatchString(s), "label key %q", s)
+		assert.Equal(t, isValidLabelValue(s), valuePattern.MatchString(s), "label value %q", s)
+	})
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		yamlDoc  string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			yamlDoc:  "apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\n  labels:\nspec:\n  context:\n    count: 1\n",
+			expected: nil,
+		},
+		{
+			name:     "missing apiVersion",
+			yamlDoc:  "kind: TerraformConfiguration\nmetadata:\n  name: a\n",
+			expected: []string{"missing apiVersion"},
+		},
+		{
+			name:     "unknown apiVersion",
+			yamlDoc:  "apiVersion: pantalon.kallan.dev/v1\nkind: TerraformConfiguration\nmetadata:\n  name: a\n",
+			expected: []string{"invalid apiVersion: expected one of pantalon.kallan.dev/v1alpha1, pantalon.kallan.dev/v1beta1"},
+		},
+		{
+			name:     "field of another version",
+			yamlDoc:  "apiVersion: pantalon.kallan.dev/v1alpha1\nkind: TerraformConfiguration\nmetadata:\n  name: a\nspec:\n  context:\n    env: prod\n",
+			expected: []string{"unknown field spec.context"},
+		},
+		{
+			name:     "every error",
+			yamlDoc:  "apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  labels:\n    tier: 1\nspec:\n  dependsOn: [Network]\n",
+			expected: []string{"missing metadata.name", "invalid metadata.labels.tier: expected string, got integer", `invalid spec.dependsOn[0]: "Network" doesn't match ^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value map[string]any
+			require.NoError(t, yaml.Unmarshal([]byte(tt.yamlDoc), &value))
+
+			var messages []string
+			for _, err := range validateSchema(Schema(), value, nil) {
+				messages = append(messages, err.Message)
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+// Unmarshal checks the schema in strict mode, but the schema is also used by editors, so it must reject everything the
+// strict check does. They must agree on every fixture and example.
+func TestSchema_AgreesWithStrictCheck(t *testing.T) {
+	var paths []string
+	for _, root := range []string{filepath.Join("..", "testdata"), filepath.Join("..", "examples")} {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && d.Name() == "pantalon.yaml" {
+				paths = append(paths, path)
+			}
+			return err
+		})
+		require.NoError(t, err)
+	}
+	require.NotEmpty(t, paths)
+
+	docs := map[string][]byte{
+		"unknown field":          []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\n  nmae: b\n"),
+		"top-level context":      []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\ncontext:\n  foo: bar\n"),
+		"unquoted label":         []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\n  labels:\n    tier: 1\n"),
+		"invalid kind":           []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: Terraform\nmetadata:\n  name: a\n"),
+		"typed context":          []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\nspec:\n  context:\n    count: 1\n    regions: [eu, us]\n"),
+		"v1alpha1 typed context": []byte("apiVersion: pantalon.kallan.dev/v1alpha1\nkind: TerraformConfiguration\nmetadata:\n  name: a\ncontext:\n  count: 1\n"),
+		"matrix":                 []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\nspec:\n  matrix:\n    region: [eu, us]\n    include:\n      - region: eu\n        zone: b\n"),
+		"matrix of objects":      []byte("apiVersion: pantalon.kallan.dev/v1beta1\nkind: TerraformConfiguration\nmetadata:\n  name: a\nspec:\n  matrix:\n    region: [{name: eu}]\n"),
+	}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		require.NoError(t, err)
+		docs[path] = b
+	}
+
+	for name, b := range docs {
+		t.Run(name, func(t *testing.T) {
+			file, err := parser.ParseBytes(b, 0)
+			require.NoError(t, err)
+
+			for _, doc := range file.Docs {
+				if doc.Body == nil {
+					continue
+				}
+				var value map[string]any
+				require.NoError(t, yaml.NodeToValue(doc.Body, &value))
+
+				schemaErrs := validateSchema(Schema(), value, nil)
+
+				single := &ast.File{Docs: []*ast.DocumentNode{doc}}
+				_, err = NewStrict().Unmarshal([]byte(single.String()))
+
+				assert.Equal(t, err == nil, len(schemaErrs) == 0, "strict check: %v\nschema: %v", err, schemaErrs)
+			}
+		})
+	}
+}
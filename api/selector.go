@@ -0,0 +1,173 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+type Operator string
+
+const (
+	OpExists       Operator = "exists"
+	OpDoesNotExist Operator = "!"
+	OpEquals       Operator = "="
+	OpNotEquals    Operator = "!="
+	OpIn           Operator = "in"
+	OpNotIn        Operator = "notin"
+)
+
+// Requirement is a single expression within a label selector, such as
+// `tier=prod` or `team in (platform,data)`.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Selector is a set of requirements which must all match (AND logic).
+type Selector []Requirement
+
+var setRequirementRegexp = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// ParseSelector parses a Kubernetes-style label selector.
+//
+// Requirements are separated by commas and support equality (`=`, `==`, `!=`),
+// set (`in`, `notin`) and existence (`key`, `!key`) expressions.
+//
+// As described in https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors
+func ParseSelector(s string) (Selector, error) {
+	terms, err := splitSelector(s)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := make(Selector, 0, len(terms))
+	for _, term := range terms {
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		selector = append(selector, r)
+	}
+	return selector, nil
+}
+
+// Matches reports whether labels satisfy every requirement in the selector.
+// An empty selector matches everything.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether labels satisfy the requirement.
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+
+	switch r.Operator {
+	case OpExists:
+		return ok
+	case OpDoesNotExist:
+		return !ok
+	case OpEquals, OpIn:
+		return ok && slices.Contains(r.Values, value)
+	case OpNotEquals, OpNotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	}
+	return false
+}
+
+// splitSelector splits on commas which are not within a parenthesised set.
+func splitSelector(s string) ([]string, error) {
+	var terms []string
+	depth := 0
+	start := 0
+
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("invalid selector %q: unbalanced parentheses", s)
+			}
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("invalid selector %q: unbalanced parentheses", s)
+	}
+
+	terms = append(terms, s[start:])
+
+	result := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			if len(terms) == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid selector %q: empty requirement", s)
+		}
+		result = append(result, term)
+	}
+	return result, nil
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	if m := setRequirementRegexp.FindStringSubmatch(term); m != nil {
+		r := Requirement{Key: m[1], Operator: Operator(m[2])}
+		if strings.TrimSpace(m[3]) == "" {
+			return r, fmt.Errorf("empty set for key %q", r.Key)
+		}
+		for _, v := range strings.Split(m[3], ",") {
+			r.Values = append(r.Values, strings.TrimSpace(v))
+		}
+		return r, r.validate()
+	}
+
+	for _, op := range []string{"!=", "==", "="} {
+		if i := strings.Index(term, op); i >= 0 {
+			operator := Operator(op)
+			if op == "==" {
+				operator = OpEquals
+			}
+			r := Requirement{
+				Key:      strings.TrimSpace(term[:i]),
+				Operator: operator,
+				Values:   []string{strings.TrimSpace(term[i+len(op):])},
+			}
+			return r, r.validate()
+		}
+	}
+
+	if strings.HasPrefix(term, "!") {
+		r := Requirement{Key: strings.TrimSpace(term[1:]), Operator: OpDoesNotExist}
+		return r, r.validate()
+	}
+
+	r := Requirement{Key: term, Operator: OpExists}
+	return r, r.validate()
+}
+
+func (r Requirement) validate() error {
+	if !isValidLabelKey(r.Key) {
+		return fmt.Errorf("invalid label key %q", r.Key)
+	}
+	for _, v := range r.Values {
+		if !isValidLabelValue(v) {
+			return fmt.Errorf("invalid label value %q", v)
+		}
+	}
+	return nil
+}
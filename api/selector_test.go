@@ -0,0 +1,115 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Selector
+	}{
+		{
+			name:     "Empty",
+			input:    "",
+			expected: Selector{},
+		},
+		{
+			name:     "Equality",
+			input:    "tier=prod",
+			expected: Selector{{Key: "tier", Operator: OpEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "Double equality",
+			input:    "tier == prod",
+			expected: Selector{{Key: "tier", Operator: OpEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "Inequality",
+			input:    "tier!=prod",
+			expected: Selector{{Key: "tier", Operator: OpNotEquals, Values: []string{"prod"}}},
+		},
+		{
+			name:     "Exists",
+			input:    "example.com/team",
+			expected: Selector{{Key: "example.com/team", Operator: OpExists}},
+		},
+		{
+			name:     "Does not exist",
+			input:    "!team",
+			expected: Selector{{Key: "team", Operator: OpDoesNotExist}},
+		},
+		{
+			name:  "Set and equality",
+			input: "tier=prod,team in (platform, data)",
+			expected: Selector{
+				{Key: "tier", Operator: OpEquals, Values: []string{"prod"}},
+				{Key: "team", Operator: OpIn, Values: []string{"platform", "data"}},
+			},
+		},
+		{
+			name:     "Not in",
+			input:    "team notin (platform)",
+			expected: Selector{{Key: "team", Operator: OpNotIn, Values: []string{"platform"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseSelector(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestParseSelector_Invalid(t *testing.T) {
+	for _, input := range []string{
+		"team in (platform",
+		"team in ()",
+		"tier=prod,,team",
+		"tier=prod_",
+		"_tier=prod",
+		"Example.com/tier=prod",
+	} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseSelector(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"tier": "prod", "team": "platform"}
+
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{"", true},
+		{"tier=prod", true},
+		{"tier=dev", false},
+		{"tier!=dev", true},
+		{"region!=eu", true},
+		{"team in (platform,data)", true},
+		{"team notin (platform,data)", false},
+		{"region notin (eu)", true},
+		{"region in (eu)", false},
+		{"team", true},
+		{"!team", false},
+		{"!region", true},
+		{"tier=prod,team in (data)", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			s, err := ParseSelector(tt.selector)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Matches(labels))
+		})
+	}
+}
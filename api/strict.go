@@ -0,0 +1,262 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml/ast"
+)
+
+// strictCheck returns an error for every unknown field and every non-string scalar used where a string is
+// expected, by comparing the document against the yaml tags of t.
+//
+// Structural mismatches, such as a sequence used where a mapping is expected, are already rejected when decoding,
+// and duplicate keys by duplicateKeys.
+func strictCheck(file *ast.File, t reflect.Type) []*ValidationError {
+	fields := knownFields(t)
+
+	var errs []*ValidationError
+	for _, doc := range file.Docs {
+		if doc.Body != nil {
+			errs = append(errs, checkNode(doc.Body, t, nil, fields)...)
+		}
+	}
+	return errs
+}
+
+// duplicateKeys returns an error for every key of a mapping within file which is already defined in the mapping.
+func duplicateKeys(file *ast.File) []*ValidationError {
+	var errs []*ValidationError
+	var walk func(node ast.Node, segments []any)
+	walk = func(node ast.Node, segments []any) {
+		switch n := node.(type) {
+		case *ast.AnchorNode:
+			walk(n.Value, segments)
+		case *ast.TagNode:
+			walk(n.Value, segments)
+		case *ast.SequenceNode:
+			for i, value := range n.Values {
+				walk(value, appendSegment(segments, i))
+			}
+		default:
+			defined := make(map[string]int)
+			for _, mv := range mappingValues(node) {
+				key := mappingKey(mv)
+				field := appendSegment(segments, key)
+				if line, ok := defined[key]; ok {
+					err := newValidationError(fmt.Sprintf("duplicate key %s, already defined at line %d", fieldName(field), line), field...)
+					setPosition(err, mv.Key)
+					errs = append(errs, err)
+					continue
+				}
+				defined[key] = 0
+				if tk := mv.Key.GetToken(); tk != nil && tk.Position != nil {
+					defined[key] = tk.Position.Line
+				}
+				walk(mv.Value, field)
+			}
+		}
+	}
+
+	for _, doc := range file.Docs {
+		if doc.Body != nil {
+			walk(doc.Body, nil)
+		}
+	}
+	return errs
+}
+
+func checkNode(node ast.Node, t reflect.Type, segments []any, fields map[string][]string) []*ValidationError {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch node.(type) {
+	case *ast.NullNode, *ast.AliasNode, *ast.AnchorNode, *ast.TagNode:
+		return nil
+	}
+
+	var errs []*ValidationError
+	switch t.Kind() {
+	case reflect.Struct:
+		structFields := yamlFields(t)
+		for _, mv := range mappingValues(node) {
+			key := mappingKey(mv)
+			field, ok := structFields[key]
+			if !ok {
+				errs = append(errs, unknownFieldError(mv, key, segments, structFields, fields))
+				continue
+			}
+			errs = append(errs, checkNode(mv.Value, field.Type, appendSegment(segments, key), fields)...)
+		}
+	case reflect.Map:
+		for _, mv := range mappingValues(node) {
+			key := mappingKey(mv)
+			errs = append(errs, checkNode(mv.Value, t.Elem(), appendSegment(segments, key), fields)...)
+		}
+	case reflect.Slice:
+		if seq, ok := node.(*ast.SequenceNode); ok {
+			for i, value := range seq.Values {
+				errs = append(errs, checkNode(value, t.Elem(), appendSegment(segments, i), fields)...)
+			}
+		}
+	case reflect.String:
+		switch node.(type) {
+		case *ast.StringNode, *ast.LiteralNode:
+		default:
+			err := newValidationError(fmt.Sprintf("invalid %s: %s must be quoted as a string", fieldName(segments), strings.ToLower(node.Type().String())), segments...)
+			setPosition(err, node)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func unknownFieldError(mv *ast.MappingValueNode, key string, segments []any, structFields map[string]reflect.StructField, fields map[string][]string) *ValidationError {
+	field := appendSegment(segments, key)
+	message := fmt.Sprintf("unknown field %s", fieldName(field))
+
+	candidates := make([]string, 0, len(structFields))
+	for name := range structFields {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	if suggestion, ok := closest(key, candidates); ok {
+		message += fmt.Sprintf(", did you mean %s?", fieldName(appendSegment(segments, suggestion)))
+	} else if paths, ok := fields[key]; ok {
+		message += fmt.Sprintf(", did you mean %s?", strings.Join(paths, " or "))
+	}
+
+	err := newValidationError(message, field...)
+	setPosition(err, mv.Key)
+	return err
+}
+
+// knownFields returns the path of every field of t, keyed by its name.
+func knownFields(t reflect.Type) map[string][]string {
+	result := make(map[string][]string)
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		for name, field := range yamlFields(t) {
+			path := name
+			if prefix != "" {
+				path = prefix + "." + name
+			}
+			result[name] = append(result[name], path)
+			walk(field.Type, path)
+		}
+	}
+	walk(t, "")
+
+	for _, paths := range result {
+		sort.Strings(paths)
+	}
+	return result
+}
+
+// yamlFields returns the fields of a struct keyed by their yaml name, excluding fields tagged `yaml:"-"`.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, ok := yamlField(t.Field(i)); ok {
+			fields[name] = t.Field(i)
+		}
+	}
+	return fields
+}
+
+// yamlField returns the yaml name of field and whether it is omitted when empty, or false if it isn't decoded.
+func yamlField(field reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	return name, strings.Contains(options, "omitempty"), true
+}
+
+func mappingValues(node ast.Node) []*ast.MappingValueNode {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		return n.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{n}
+	}
+	return nil
+}
+
+func mappingKey(mv *ast.MappingValueNode) string {
+	if s, ok := mv.Key.(*ast.StringNode); ok {
+		return s.Value
+	}
+	return mv.Key.GetToken().Value
+}
+
+func appendSegment(segments []any, segment any) []any {
+	return append(append(make([]any, 0, len(segments)+1), segments...), segment)
+}
+
+func fieldName(segments []any) string {
+	return newValidationError("", segments...).Field
+}
+
+func setPosition(err *ValidationError, node ast.Node) {
+	if tk := node.GetToken(); tk != nil && tk.Position != nil {
+		err.Line = tk.Position.Line
+		err.Column = tk.Position.Column
+	}
+}
+
+// closest returns the candidate nearest to s, if it is a plausible misspelling.
+func closest(s string, candidates []string) (string, bool) {
+	best := ""
+	bestDistance := -1
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(s), strings.ToLower(c))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+
+	// Allow roughly one edit for every three characters.
+	if bestDistance < 0 || bestDistance > max(1, len(s)/3) {
+		return "", false
+	}
+	return best, true
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalStrict_UnknownFields(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metdata:
+  name: hello-world
+dependsOn:
+  - network
+contxt:
+  foo: bar
+spec:
+  ignroe:
+    - "*.md"
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{
+		"unknown field metdata, did you mean metadata?",
+		"unknown field dependsOn, did you mean spec.dependsOn?",
+		"unknown field contxt, did you mean context?",
+		"unknown field spec.ignroe, did you mean spec.ignore?",
+		"invalid metadata.name",
+	}, messages)
+
+	assert.Equal(t, "metdata", errs[0].Field)
+	assert.Equal(t, 4, errs[0].Line)
+	assert.Equal(t, 1, errs[0].Column)
+	assert.Equal(t, "spec.ignroe", errs[3].Field)
+	assert.Equal(t, 11, errs[3].Line)
+	assert.Equal(t, 3, errs[3].Column)
+}
+
+func TestUnmarshalStrict_DuplicateKeys(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  labels:
+    tier: prod
+spec:
+  dependsOn: [network]
+  ignroe: ["*.md"]
+  dependsOn: [database]
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, "duplicate key spec.dependsOn, already defined at line 8", errs[0].Message)
+	assert.Equal(t, 10, errs[0].Line)
+	assert.Equal(t, 3, errs[0].Column)
+
+	assert.Equal(t, "unknown field spec.ignroe, did you mean spec.ignore?", errs[1].Message)
+	assert.Equal(t, 9, errs[1].Line)
+
+	assert.Equal(t, "invalid metadata.name", errs[2].Message)
+	assert.Equal(t, 4, errs[2].Line)
+	assert.Equal(t, 1, errs[2].Column)
+
+	_, err = New().UnmarshalAll([]byte(yamlDoc + yamlDoc))
+	assert.EqualError(t, err, "duplicate key spec.dependsOn, already defined at line 8\ninvalid metadata.name\n"+
+		"duplicate key spec.dependsOn, already defined at line 18\ninvalid metadata.name")
+}
+
+func TestUnmarshalStrict_UnquotedScalars(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    tier: 1
+context:
+  enabled: true
+  region: ap-southeast-2
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "invalid metadata.labels.tier: integer must be quoted as a string", errs[0].Message)
+	assert.Equal(t, 7, errs[0].Line)
+	assert.Equal(t, "invalid context.enabled: bool must be quoted as a string", errs[1].Message)
+	assert.Equal(t, 9, errs[1].Line)
+}
+
+func TestUnmarshalStrict_DefaultAllowsUnknownFields(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+contxt:
+  enabled: true
+`
+	_, err := New().Unmarshal([]byte(yamlDoc))
+	assert.NoError(t, err)
+
+	_, err = NewStrict().Unmarshal([]byte(yamlDoc))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalStrict_ValidFile(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    tier: "1"
+spec:
+  dependsOn:
+    - network
+context:
+  region: ap-southeast-2
+  script: |
+    terraform apply
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+	assert.NoError(t, err)
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"apiVersion", "context", "kind", "metadata", "spec"}
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"contxt", "context", true},
+		{"Metadata", "metadata", true},
+		{"knd", "kind", true},
+		{"labels", "", false},
+		{"x", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := closest(tt.input, candidates)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
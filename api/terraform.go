@@ -2,103 +2,339 @@ package api
 
 import (
 	"errors"
+	"fmt"
 	"path"
+	"reflect"
 	"regexp"
+	"slices"
+	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
 )
 
 const (
-	PantalonVersion = "pantalon.kallan.dev/v1alpha1"
+	// PantalonVersion is the first apiVersion.
+	//
+	// Deprecated: use V1Alpha1, or LatestVersion for new files.
+	PantalonVersion = V1Alpha1
 	TerraformKind   = "TerraformConfiguration"
 )
 
+// subdomainLabelPattern matches an RFC 1123 subdomain label, ignoring its length.
+const subdomainLabelPattern = `[a-z0-9]([a-z0-9-]*[a-z0-9])?`
+
+var subdomainLabelRegexp = regexp.MustCompile(`^` + subdomainLabelPattern + `$`)
+
 type PantalonConfig interface {
 	New() config
 	Unmarshal([]byte) (TerraformConfiguration, error)
+	UnmarshalAll([]byte) ([]TerraformConfiguration, error)
 }
 
 type config struct {
+	// strict rejects unknown fields, non-string scalars used where a string is expected, and anything else the Schema
+	// rejects.
+	strict bool
 }
 
+// TerraformConfiguration is the hub every apiVersion is converted to, so the rest of pantalon is independent of the
+// version of each pantalon.yaml. ApiVersion is the version the file was read from.
 type TerraformConfiguration struct {
-	ApiVersion string            `yaml:"apiVersion"`
-	Kind       string            `yaml:"kind"`
-	Metadata   Metadata          `yaml:"metadata"`
-	Context    map[string]string `yaml:"context,omitempty"`
-	Path       string
+	ApiVersion string         `yaml:"apiVersion"`
+	Kind       string         `yaml:"kind"`
+	Metadata   Metadata       `yaml:"metadata"`
+	Spec       Spec           `yaml:"spec,omitempty"`
+	Context    map[string]any `yaml:"context,omitempty"`
+	Path       string         `yaml:"-"`
+	// ContextSources is the path of the file each key of Context was inherited from. Keys set by the configuration
+	// itself aren't included.
+	ContextSources map[string]string `yaml:"-"`
+	// Document is the index of the document within a file of several, or nil for a file of one.
+	Document *int `yaml:"-"`
+
+	// ignoredContext is set when a document read without strict checking has a top-level context, which its
+	// apiVersion ignores.
+	ignoredContext bool
 }
 
 type ConfigurationItem struct {
-	Name    string            `yaml:"name"`
-	Path    string            `yaml:"path"`
-	Dir     string            `yaml:"dir"`
-	Context map[string]string `yaml:"context"`
+	Name      string            `yaml:"name"`
+	Path      string            `yaml:"path"`
+	Dir       string            `yaml:"dir"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	DependsOn []string          `yaml:"dependsOn,omitempty"`
+	Modules   []string          `yaml:"modules,omitempty"`
+	Ignore    []string          `yaml:"ignore,omitempty"`
+	Context   map[string]any    `yaml:"context"`
+	// Matrix is the combination of spec.matrix values of an item expanded from a matrix, which are also merged into
+	// Context.
+	Matrix map[string]any `yaml:"matrix,omitempty"`
+	// ContextSources is the path of the file each inherited key of Context came from, for debugging.
+	ContextSources map[string]string `yaml:"contextSources,omitempty"`
+	// Document is the index of the document within Path, if it contains several.
+	Document *int `yaml:"document,omitempty"`
 }
 
 type Metadata struct {
-	Name string `yaml:"name"`
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type Spec struct {
+	// DependsOn lists the metadata.name of configurations which must be applied before this one.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// Ignore lists doublestar patterns of changed files, relative to the configuration directory, which do not
+	// cause the configuration to be considered changed.
+	Ignore []string `yaml:"ignore,omitempty"`
+	// Matrix expands the configuration into an item for each combination of the values of its keys, with include
+	// and exclude rules like the matrix of a GitHub Actions job. The order of the keys is kept for derived names.
+	Matrix yaml.MapSlice `yaml:"matrix,omitempty"`
 }
 
 func New() config {
 	return config{}
 }
 
+// NewStrict returns a config which rejects unknown fields, such as a misspelt `metdata`, and non-string
+// scalars used where a string is expected. Each document is also checked against the Schema.
+func NewStrict() config {
+	return config{strict: true}
+}
+
+// Unmarshal decodes a pantalon.yaml document of any supported apiVersion, converting it to the hub.
+//
+// A document with an unknown apiVersion is decoded as LatestVersion, so the errors of its other fields are also
+// reported.
 func (c config) Unmarshal(yamlDoc []byte) (TerraformConfiguration, error) {
+	file, err := parser.ParseBytes(yamlDoc, 0, parser.AllowDuplicateMapKey())
+	if err != nil {
+		return TerraformConfiguration{}, decodeError(err)
+	}
+	return c.unmarshal(file, func(v any) error { return yaml.UnmarshalWithOptions(yamlDoc, v, yaml.AllowDuplicateMapKey()) })
+}
+
+// UnmarshalAll decodes every document of a pantalon.yaml, each a separate configuration. Documents without content,
+// such as comments before the first `---`, are skipped. If there's more than one, the Document of each configuration
+// is its index.
+//
+// If any document is invalid, the errors of every invalid document are returned, positioned within the file.
+func (c config) UnmarshalAll(yamlDoc []byte) ([]TerraformConfiguration, error) {
+	file, err := parser.ParseBytes(yamlDoc, 0, parser.AllowDuplicateMapKey())
+	if err != nil {
+		return nil, decodeError(err)
+	}
+
+	var docs []*ast.DocumentNode
+	for _, doc := range file.Docs {
+		switch doc.Body.(type) {
+		case nil, *ast.CommentGroupNode:
+		default:
+			docs = append(docs, doc)
+		}
+	}
+	if len(docs) <= 1 {
+		cfg, err := c.Unmarshal(yamlDoc)
+		if err != nil {
+			return nil, err
+		}
+		return []TerraformConfiguration{cfg}, nil
+	}
+
+	result := make([]TerraformConfiguration, 0, len(docs))
+	var errs []error
+	for i, doc := range docs {
+		cfg, err := c.unmarshal(&ast.File{Name: file.Name, Docs: []*ast.DocumentNode{doc}}, func(v any) error {
+			return yaml.NodeToValue(doc.Body, v, yaml.AllowDuplicateMapKey())
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cfg.Document = &i
+		result = append(result, cfg)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// unmarshal decodes the single document of file with decode, which decodes it into a value. Duplicate keys are
+// allowed by decode, so they're reported with the other errors of the document.
+func (c config) unmarshal(file *ast.File, decode func(v any) error) (TerraformConfiguration, error) {
 	cfg := TerraformConfiguration{}
 
-	err := yaml.Unmarshal(yamlDoc, &cfg)
+	var meta typeMeta
+	err := decode(&meta)
 	if err != nil {
-		return cfg, err
+		return cfg, decodeError(err)
+	}
+
+	version, known := apiVersions[meta.ApiVersion]
+	if !known {
+		version = apiVersions[LatestVersion]
 	}
 
-	err = c.validateTerraform(cfg)
+	doc := version.newConfiguration()
+	err = decode(doc)
 	if err != nil {
-		return cfg, err
+		return cfg, decodeError(err)
+	}
+	cfg = doc.toHub()
+
+	errs := duplicateKeys(file)
+	var schemaErrs []*ValidationError
+	if c.strict && known {
+		errs = append(errs, strictCheck(file, reflect.TypeOf(doc).Elem())...)
+
+		var value map[string]any
+		if err := decode(&value); err != nil {
+			return cfg, decodeError(err)
+		}
+		schemaErrs = validateSchema(generatedSchema(), value, nil)
+	} else if known {
+		_, hasContext := yamlFields(reflect.TypeOf(doc).Elem())["context"]
+		cfg.ignoredContext = !hasContext && hasTopLevelKey(file, "context")
+	}
+
+	errs = append(errs, c.validateTerraform(cfg)...)
+	// The schema rejects most of what is checked above, so only the errors of other fields are added.
+	for _, err := range schemaErrs {
+		if !slices.ContainsFunc(errs, func(e *ValidationError) bool { return relatedFields(e.Field, err.Field) }) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		locate(file, errs)
+		return cfg, joinValidationErrors(errs)
 	}
 
 	return cfg, nil
 }
 
-func (c config) validateTerraform(cfg TerraformConfiguration) error {
-	if cfg.ApiVersion != PantalonVersion {
-		return errors.New("invalid version")
+// relatedFields reports whether the fields a and b are the same, or one is within the other.
+func relatedFields(a, b string) bool {
+	within := func(field, parent string) bool {
+		return strings.HasPrefix(field, parent) && (len(field) == len(parent) || field[len(parent)] == '.' || field[len(parent)] == '[')
+	}
+	return within(a, b) || within(b, a)
+}
+
+// hasTopLevelKey reports whether the first document of file is a mapping with the key name.
+func hasTopLevelKey(file *ast.File, name string) bool {
+	for _, doc := range file.Docs {
+		for _, mv := range mappingValues(doc.Body) {
+			if mappingKey(mv) == name {
+				return true
+			}
+		}
+		if doc.Body != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// validateTerraform returns an error for every invalid field of cfg.
+func (c config) validateTerraform(cfg TerraformConfiguration) []*ValidationError {
+	var errs []*ValidationError
+
+	version, ok := apiVersions[cfg.ApiVersion]
+	if !ok {
+		errs = append(errs, newValidationError("invalid version", "apiVersion"))
+		version = apiVersions[LatestVersion]
 	}
 
 	if cfg.Kind != TerraformKind {
-		return errors.New("invalid kind")
+		errs = append(errs, newValidationError("invalid kind", "kind"))
 	}
 
 	if !isValidSubdomainLabel(cfg.Metadata.Name) {
-		return errors.New("invalid metadata.name")
+		errs = append(errs, newValidationError("invalid metadata.name", "metadata", "name"))
+	}
+
+	errs = append(errs, validateLabels(cfg.Metadata.Labels)...)
+	errs = append(errs, validateMatrix(cfg)...)
+	errs = append(errs, validateReferences(cfg.Context, version.contextPath)...)
+
+	for i, dep := range cfg.Spec.DependsOn {
+		if !isValidSubdomainLabel(dep) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.dependsOn %q", dep), "spec", "dependsOn", i))
+		} else if dep == cfg.Metadata.Name {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.dependsOn %q: configuration cannot depend on itself", dep), "spec", "dependsOn", i))
+		}
+	}
+
+	for i, pattern := range cfg.Spec.Ignore {
+		if !doublestar.ValidatePattern(pattern) {
+			errs = append(errs, newValidationError(fmt.Sprintf("invalid spec.ignore %q", pattern), "spec", "ignore", i))
+		}
 	}
-	return nil
+	return errs
 }
 
+// MarshalItems marshals configurations into items, expanding the references of their context values.
 func MarshalItems(cfgs []TerraformConfiguration) ([]ConfigurationItem, error) {
+	return MarshalOptions{}.MarshalItems(cfgs)
+}
+
+// MarshalItems marshals configurations into items with the options o. The errors of every item with an invalid
+// reference are returned.
+func (o MarshalOptions) MarshalItems(cfgs []TerraformConfiguration) ([]ConfigurationItem, error) {
 
 	items := make([]ConfigurationItem, 0)
+	matrixNames := make(map[string][]string)
+	var errs []error
 
 	for _, cfg := range cfgs {
 		item := ConfigurationItem{
-			Name:    cfg.Metadata.Name,
-			Context: cfg.Context,
-			Path:    cfg.Path,
-			Dir:     path.Dir(cfg.Path),
+			Name:      cfg.Metadata.Name,
+			Labels:    cfg.Metadata.Labels,
+			DependsOn: cfg.Spec.DependsOn,
+			Ignore:    cfg.Spec.Ignore,
+			Context:   cfg.Context,
+			Path:      cfg.Path,
+			Dir:       path.Dir(cfg.Path),
+
+			ContextSources: cfg.ContextSources,
+			Document:       cfg.Document,
+		}
+
+		expanded, err := expandMatrix(item, cfg.Spec.Matrix)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if cfg.Spec.Matrix != nil {
+			for _, item := range expanded {
+				matrixNames[cfg.Metadata.Name] = append(matrixNames[cfg.Metadata.Name], item.Name)
+			}
+		}
+		for _, item := range expanded {
+			context, err := o.interpolate(item)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			item.Context = context
+			items = append(items, item)
 		}
-		items = append(items, item)
 	}
 
-	return items, nil
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return expandDependencies(items, matrixNames), nil
 }
 
 // Must comply with RFC 1123 subdomain labels
 //
 // As described in https://kubernetes.io/docs/concepts/overview/working-with-objects/names/#dns-subdomain-names
 func isValidSubdomainLabel(s string) bool {
-	reg := regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
-
-	if !reg.MatchString(s) {
+	if !subdomainLabelRegexp.MatchString(s) {
 		return false
 	}
 
@@ -98,6 +98,108 @@ metadata:
 	assert.EqualError(t, err, "invalid metadata.name")
 }
 
+func TestUnmarshalTerraformConfiguration_WithLabels(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    tier: prod
+    example.com/team: platform
+`
+	cfg := config{}
+	tfCfg, err := cfg.Unmarshal([]byte(yamlDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, map[string]string{"tier": "prod", "example.com/team": "platform"}, tfCfg.Metadata.Labels)
+}
+
+func TestUnmarshalTerraformConfiguration_InvalidLabelKey(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    -tier: prod
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+	assert.EqualError(t, err, `invalid metadata.labels key "-tier"`)
+}
+
+func TestUnmarshalTerraformConfiguration_InvalidLabelValue(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    tier: prod env
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+	assert.EqualError(t, err, `invalid metadata.labels value "prod env" for key "tier"`)
+}
+
+func TestUnmarshalTerraformConfiguration_WithDependsOn(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  dependsOn:
+    - network-prod
+`
+	cfg := config{}
+	tfCfg, err := cfg.Unmarshal([]byte(yamlDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{"network-prod"}, tfCfg.Spec.DependsOn)
+}
+
+func TestUnmarshalTerraformConfiguration_DependsOnSelf(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  dependsOn:
+    - compute-prod
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+	assert.EqualError(t, err, `invalid spec.dependsOn "compute-prod": configuration cannot depend on itself`)
+}
+
+func TestUnmarshalTerraformConfiguration_InvalidIgnorePattern(t *testing.T) {
+	yamlDoc := `
+---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+spec:
+  ignore:
+    - "docs/[*.md"
+`
+	cfg := config{}
+	_, err := cfg.Unmarshal([]byte(yamlDoc))
+	assert.EqualError(t, err, `invalid spec.ignore "docs/[*.md"`)
+}
+
 func TestMarshalItems(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -109,14 +211,14 @@ func TestMarshalItems(t *testing.T) {
 			input: []TerraformConfiguration{
 				{
 					Metadata: Metadata{Name: "item1"},
-					Context:  map[string]string{"key1": "value1"},
+					Context:  map[string]any{"key1": "value1"},
 					Path:     "/path/to/item1/pantalon.yaml",
 				},
 			},
 			expected: []ConfigurationItem{
 				{
 					Name:    "item1",
-					Context: map[string]string{"key1": "value1"},
+					Context: map[string]any{"key1": "value1"},
 					Path:    "/path/to/item1/pantalon.yaml",
 					Dir:     "/path/to/item1",
 				},
@@ -127,25 +229,25 @@ func TestMarshalItems(t *testing.T) {
 			input: []TerraformConfiguration{
 				{
 					Metadata: Metadata{Name: "item1"},
-					Context:  map[string]string{"key1": "value1"},
+					Context:  map[string]any{"key1": "value1"},
 					Path:     "/path/to/item1/pantalon.yaml",
 				},
 				{
 					Metadata: Metadata{Name: "item2"},
-					Context:  map[string]string{"key2": "value2"},
+					Context:  map[string]any{"key2": "value2"},
 					Path:     "/path/to/item2/pantalon.yaml",
 				},
 			},
 			expected: []ConfigurationItem{
 				{
 					Name:    "item1",
-					Context: map[string]string{"key1": "value1"},
+					Context: map[string]any{"key1": "value1"},
 					Path:    "/path/to/item1/pantalon.yaml",
 					Dir:     "/path/to/item1",
 				},
 				{
 					Name:    "item2",
-					Context: map[string]string{"key2": "value2"},
+					Context: map[string]any{"key2": "value2"},
 					Path:    "/path/to/item2/pantalon.yaml",
 					Dir:     "/path/to/item2",
 				},
@@ -156,19 +258,36 @@ func TestMarshalItems(t *testing.T) {
 			input: []TerraformConfiguration{
 				{
 					Metadata: Metadata{Name: "item1"},
-					Context:  map[string]string{},
+					Context:  map[string]any{},
 					Path:     "/path/to/item1/pantalon.yaml",
 				},
 			},
 			expected: []ConfigurationItem{
 				{
 					Name:    "item1",
-					Context: map[string]string{},
+					Context: map[string]any{},
 					Path:    "/path/to/item1/pantalon.yaml",
 					Dir:     "/path/to/item1",
 				},
 			},
 		},
+		{
+			name: "With labels",
+			input: []TerraformConfiguration{
+				{
+					Metadata: Metadata{Name: "item1", Labels: map[string]string{"tier": "prod"}},
+					Path:     "/path/to/item1/pantalon.yaml",
+				},
+			},
+			expected: []ConfigurationItem{
+				{
+					Name:   "item1",
+					Labels: map[string]string{"tier": "prod"},
+					Path:   "/path/to/item1/pantalon.yaml",
+					Dir:    "/path/to/item1",
+				},
+			},
+		},
 		{
 			name:     "No items",
 			input:    []TerraformConfiguration{},
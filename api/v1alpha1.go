@@ -0,0 +1,71 @@
+package api
+
+import "fmt"
+
+// terraformConfigurationV1Alpha1 is a pantalon.kallan.dev/v1alpha1 document, with context at the top level. Context
+// values are strings, so a number or boolean is read as its text.
+type terraformConfigurationV1Alpha1 struct {
+	ApiVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   Metadata          `yaml:"metadata"`
+	Context    map[string]string `yaml:"context,omitempty"`
+	Spec       specV1Alpha1      `yaml:"spec,omitempty"`
+}
+
+type specV1Alpha1 struct {
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	Ignore    []string `yaml:"ignore,omitempty"`
+}
+
+func (c *terraformConfigurationV1Alpha1) toHub() TerraformConfiguration {
+	return TerraformConfiguration{
+		ApiVersion: c.ApiVersion,
+		Kind:       c.Kind,
+		Metadata:   c.Metadata,
+		Spec: Spec{
+			DependsOn: c.Spec.DependsOn,
+			Ignore:    c.Spec.Ignore,
+		},
+		Context: stringContextToHub(c.Context),
+	}
+}
+
+func v1alpha1FromHub(cfg TerraformConfiguration) *terraformConfigurationV1Alpha1 {
+	return &terraformConfigurationV1Alpha1{
+		ApiVersion: V1Alpha1,
+		Kind:       cfg.Kind,
+		Metadata:   cfg.Metadata,
+		Spec: specV1Alpha1{
+			DependsOn: cfg.Spec.DependsOn,
+			Ignore:    cfg.Spec.Ignore,
+		},
+		Context: stringContextFromHub(cfg.Context),
+	}
+}
+
+func stringContextToHub(context map[string]string) map[string]any {
+	if context == nil {
+		return nil
+	}
+	result := make(map[string]any, len(context))
+	for k, v := range context {
+		result[k] = v
+	}
+	return result
+}
+
+// stringContextFromHub returns the text of each context value, as v1alpha1 only has string values.
+func stringContextFromHub(context map[string]any) map[string]string {
+	if context == nil {
+		return nil
+	}
+	result := make(map[string]string, len(context))
+	for k, v := range context {
+		if s, ok := v.(string); ok {
+			result[k] = s
+		} else {
+			result[k] = fmt.Sprint(v)
+		}
+	}
+	return result
+}
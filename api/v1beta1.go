@@ -0,0 +1,47 @@
+package api
+
+import "github.com/goccy/go-yaml"
+
+// terraformConfigurationV1Beta1 is a pantalon.kallan.dev/v1beta1 document. Everything describing the configuration,
+// including context, is within spec, so new fields don't collide with apiVersion, kind and metadata.
+type terraformConfigurationV1Beta1 struct {
+	ApiVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   Metadata    `yaml:"metadata"`
+	Spec       specV1Beta1 `yaml:"spec,omitempty"`
+}
+
+type specV1Beta1 struct {
+	DependsOn []string       `yaml:"dependsOn,omitempty"`
+	Ignore    []string       `yaml:"ignore,omitempty"`
+	Context   map[string]any `yaml:"context,omitempty"`
+	Matrix    yaml.MapSlice  `yaml:"matrix,omitempty"`
+}
+
+func (c *terraformConfigurationV1Beta1) toHub() TerraformConfiguration {
+	return TerraformConfiguration{
+		ApiVersion: c.ApiVersion,
+		Kind:       c.Kind,
+		Metadata:   c.Metadata,
+		Spec: Spec{
+			DependsOn: c.Spec.DependsOn,
+			Ignore:    c.Spec.Ignore,
+			Matrix:    c.Spec.Matrix,
+		},
+		Context: c.Spec.Context,
+	}
+}
+
+func v1beta1FromHub(cfg TerraformConfiguration) *terraformConfigurationV1Beta1 {
+	return &terraformConfigurationV1Beta1{
+		ApiVersion: V1Beta1,
+		Kind:       cfg.Kind,
+		Metadata:   cfg.Metadata,
+		Spec: specV1Beta1{
+			DependsOn: cfg.Spec.DependsOn,
+			Ignore:    cfg.Spec.Ignore,
+			Context:   cfg.Context,
+			Matrix:    cfg.Spec.Matrix,
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	GroupName = "pantalon.kallan.dev"
+
+	V1Alpha1 = GroupName + "/v1alpha1"
+	V1Beta1  = GroupName + "/v1beta1"
+
+	// LatestVersion is the apiVersion new pantalon.yaml files should use.
+	LatestVersion = V1Beta1
+)
+
+// versionedConfiguration is the document of a single apiVersion, which is converted to the TerraformConfiguration hub.
+type versionedConfiguration interface {
+	toHub() TerraformConfiguration
+}
+
+// apiVersionInfo describes how to read and write an apiVersion.
+type apiVersionInfo struct {
+	// newConfiguration returns a pointer to decode a document into.
+	newConfiguration func() versionedConfiguration
+	// fromHub converts the hub to the document of this version.
+	fromHub func(TerraformConfiguration) versionedConfiguration
+	// contextPath is the path of the context within a document.
+	contextPath []any
+	// deprecated versions are read with a warning.
+	deprecated bool
+}
+
+var apiVersions = map[string]apiVersionInfo{
+	V1Alpha1: {
+		newConfiguration: func() versionedConfiguration { return &terraformConfigurationV1Alpha1{} },
+		fromHub:          func(cfg TerraformConfiguration) versionedConfiguration { return v1alpha1FromHub(cfg) },
+		contextPath:      []any{"context"},
+		deprecated:       true,
+	},
+	V1Beta1: {
+		newConfiguration: func() versionedConfiguration { return &terraformConfigurationV1Beta1{} },
+		fromHub:          func(cfg TerraformConfiguration) versionedConfiguration { return v1beta1FromHub(cfg) },
+		contextPath:      []any{"spec", "context"},
+	},
+}
+
+// ApiVersions returns every supported apiVersion, sorted.
+func ApiVersions() []string {
+	result := make([]string, 0, len(apiVersions))
+	for version := range apiVersions {
+		result = append(result, version)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// typeMeta is decoded first to find the apiVersion of a document.
+type typeMeta struct {
+	ApiVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+}
+
+// Convert returns cfg as the document of the apiVersion version, ready to be marshaled.
+func Convert(cfg TerraformConfiguration, version string) (any, error) {
+	info, ok := apiVersions[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported apiVersion %q", version)
+	}
+	return info.fromHub(cfg), nil
+}
+
+// Warnings returns a warning for each deprecated feature used by cfg, such as a deprecated apiVersion, and for a
+// top-level context its apiVersion ignores.
+func (cfg TerraformConfiguration) Warnings() []string {
+	var warnings []string
+	if info, ok := apiVersions[cfg.ApiVersion]; ok && info.deprecated {
+		warnings = append(warnings, fmt.Sprintf("apiVersion %s is deprecated, use %s", cfg.ApiVersion, LatestVersion))
+	}
+	if cfg.ignoredContext {
+		warnings = append(warnings, fmt.Sprintf("context is ignored by apiVersion %s, did you mean spec.context?", cfg.ApiVersion))
+	}
+	return warnings
+}
+
+// configurationType returns the document type of the apiVersion version.
+func configurationType(version string) (reflect.Type, bool) {
+	info, ok := apiVersions[version]
+	if !ok {
+		return nil, false
+	}
+	return reflect.TypeOf(info.newConfiguration()).Elem(), true
+}
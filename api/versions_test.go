@@ -0,0 +1,139 @@
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const v1beta1YamlDoc = `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+  labels:
+    tier: web
+spec:
+  dependsOn:
+    - network
+  ignore:
+    - "*.md"
+  context:
+    foo: bar
+`
+
+func TestUnmarshal_V1Beta1(t *testing.T) {
+	cfg, err := NewStrict().Unmarshal([]byte(v1beta1YamlDoc))
+	require.NoError(t, err)
+
+	assert.Equal(t, TerraformConfiguration{
+		ApiVersion: V1Beta1,
+		Kind:       TerraformKind,
+		Metadata:   Metadata{Name: "hello-world", Labels: map[string]string{"tier": "web"}},
+		Spec:       Spec{DependsOn: []string{"network"}, Ignore: []string{"*.md"}},
+		Context:    map[string]any{"foo": "bar"},
+	}, cfg)
+	assert.Empty(t, cfg.Warnings())
+}
+
+func TestUnmarshal_V1Alpha1ConvertsToHub(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+spec:
+  dependsOn:
+    - network
+context:
+  foo: bar
+`
+	cfg, err := NewStrict().Unmarshal([]byte(yamlDoc))
+	require.NoError(t, err)
+
+	assert.Equal(t, V1Alpha1, cfg.ApiVersion)
+	assert.Equal(t, map[string]any{"foo": "bar"}, cfg.Context)
+	assert.Equal(t, []string{"network"}, cfg.Spec.DependsOn)
+	assert.Equal(t, []string{"apiVersion pantalon.kallan.dev/v1alpha1 is deprecated, use pantalon.kallan.dev/v1beta1"}, cfg.Warnings())
+}
+
+func TestUnmarshalStrict_V1Beta1TopLevelContext(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+context:
+  foo: bar
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "unknown field context, did you mean spec.context?", errs[0].Message)
+	assert.Equal(t, 6, errs[0].Line)
+}
+
+func TestUnmarshal_V1Beta1TopLevelContextWarns(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: hello-world
+context:
+  foo: bar
+`
+	cfg, err := New().Unmarshal([]byte(yamlDoc))
+	require.NoError(t, err)
+
+	assert.Empty(t, cfg.Context)
+	assert.Equal(t, []string{"context is ignored by apiVersion pantalon.kallan.dev/v1beta1, did you mean spec.context?"}, cfg.Warnings())
+
+	cfgs, err := New().UnmarshalAll([]byte(yamlDoc + strings.Replace(yamlDoc, "hello-world", "other", 1)))
+	require.NoError(t, err)
+	require.Len(t, cfgs, 2)
+	assert.Len(t, cfgs[1].Warnings(), 1)
+}
+
+func TestUnmarshal_UnknownVersionReportsOtherErrors(t *testing.T) {
+	yamlDoc := `---
+apiVersion: pantalon.kallan.dev/v2
+kind: TerraformConfiguration
+metadata:
+  name: Hello
+`
+	_, err := NewStrict().Unmarshal([]byte(yamlDoc))
+
+	errs := validationErrors(t, err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "invalid version", errs[0].Message)
+	assert.Equal(t, "invalid metadata.name", errs[1].Message)
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	cfg, err := New().Unmarshal([]byte(v1beta1YamlDoc))
+	require.NoError(t, err)
+
+	for _, version := range ApiVersions() {
+		t.Run(version, func(t *testing.T) {
+			doc, err := Convert(cfg, version)
+			require.NoError(t, err)
+
+			b, err := yaml.Marshal(doc)
+			require.NoError(t, err)
+
+			converted, err := NewStrict().Unmarshal(b)
+			require.NoError(t, err)
+
+			expected := cfg
+			expected.ApiVersion = version
+			assert.Equal(t, expected, converted)
+		})
+	}
+
+	_, err = Convert(cfg, "pantalon.kallan.dev/v2")
+	assert.EqualError(t, err, `unsupported apiVersion "pantalon.kallan.dev/v2"`)
+}
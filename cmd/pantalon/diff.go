@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/file"
+)
+
+// runDiff implements `pantalon diff`, reporting how the configurations changed between two git refs.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon diff - compare the configurations between two git refs
+
+Discovers the pantalon.yaml files at both refs, without checking them out, and
+reports the configurations added, removed, renamed (same dir, new name), moved
+(same name, new dir) and whose context changed.
+
+Usage:
+  pantalon diff --from <ref> [--to <ref>] [flags]
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	from := flags.String("from", "", "Git ref to compare from (required)")
+	to := flags.String("to", "HEAD", "Git ref to compare to")
+	outputFormat := flags.String("output-format", defaultOutputFormat(), "Output format: json or yaml")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	if *from == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	fromItems, err := refItems(search.options(false), *from)
+	if err != nil {
+		log.Fatalf("Error listing configurations at %s: %v", *from, err)
+	}
+
+	toItems, err := refItems(search.options(false), *to)
+	if err != nil {
+		log.Fatalf("Error listing configurations at %s: %v", *to, err)
+	}
+
+	output(api.DiffInventories(fromItems, toItems), *outputFormat)
+}
+
+func refItems(opts file.Options, ref string) ([]api.ConfigurationItem, error) {
+	configurations, err := opts.SearchRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	return marshalItems(configurations)
+}
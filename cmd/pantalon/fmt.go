@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// runFmt implements `pantalon fmt`, rewriting every pantalon.yaml in canonical form.
+func runFmt(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon fmt - rewrite every pantalon.yaml in canonical form
+
+Rewrites each pantalon.yaml in place with a leading ---, keys in schema order,
+two space indentation in block style and quoted context values, keeping
+comments. Given files, only those files are formatted.
+
+With --check, files are not written. The path of each file which isn't
+formatted is printed, and the exit status is non-zero if there are any.
+
+With --diff, files are not written either. A unified diff of the changes is
+printed instead.
+
+Usage:
+  pantalon fmt [flags] [file...]
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	check := flags.Bool("check", false, "List files which aren't formatted, without writing them, and exit non-zero if there are any")
+	diff := flags.Bool("diff", false, "Print a unified diff of the changes, without writing them")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	paths := argPaths(flags.Args())
+	if len(paths) == 0 {
+		var err error
+		paths, err = search.options(false).Paths()
+		if err != nil {
+			log.Fatalf("Error listing configurations: %v", err)
+		}
+	}
+
+	var failed, unformatted bool
+	for _, path := range paths {
+		changes, err := rewriteFile(path, api.Format, !*check && !*diff)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		if changes == "" {
+			continue
+		}
+
+		unformatted = true
+		if *diff {
+			fmt.Print(changes)
+		} else if *check {
+			fmt.Println(path)
+		} else {
+			fmt.Fprintf(os.Stderr, "formatted %s\n", path)
+		}
+	}
+	if failed || (*check && unformatted) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFmtFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pantalon.yaml")
+	unformatted := `kind: TerraformConfiguration
+apiVersion: pantalon.kallan.dev/v1beta1
+metadata:
+    name: compute-prod
+`
+	require.NoError(t, os.WriteFile(path, []byte(unformatted), 0o644))
+
+	diff, err := rewriteFile(path, api.Format, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, diff)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, unformatted, string(b), "check must not write the file")
+
+	_, err = rewriteFile(path, api.Format, true)
+	require.NoError(t, err)
+
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+`, string(b))
+
+	diff, err = rewriteFile(path, api.Format, false)
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+}
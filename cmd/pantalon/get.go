@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// runGet implements `pantalon get <name>`, printing a single configuration by metadata.name.
+func runGet(args []string) {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon get - print a single configuration by name
+
+Usage:
+  pantalon get [flags] <name>
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	outputFormat := flags.String("output-format", defaultOutputFormat(), "Output format: json or yaml")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	items, err := discover(search.options(false))
+	if err != nil {
+		log.Fatalf("Error discovering configurations: %v", err)
+	}
+
+	item, err := getItem(items, flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	output(item, *outputFormat)
+}
+
+// getItem returns the item named name, which must be unique.
+func getItem(items []api.ConfigurationItem, name string) (api.ConfigurationItem, error) {
+	var found []api.ConfigurationItem
+	for _, item := range items {
+		if item.Name == name {
+			found = append(found, item)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return api.ConfigurationItem{}, fmt.Errorf("configuration %q not found", name)
+	case 1:
+		return found[0], nil
+	default:
+		return api.ConfigurationItem{}, fmt.Errorf("configuration %q is ambiguous, found %d configurations", name, len(found))
+	}
+}
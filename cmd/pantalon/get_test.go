@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetItem(t *testing.T) {
+	item, err := getItem(filterTestItems, "network-dev")
+	require.NoError(t, err)
+	assert.Equal(t, filterTestItems[2], item)
+}
+
+func TestGetItem_NotFound(t *testing.T) {
+	_, err := getItem(filterTestItems, "storage-dev")
+	assert.EqualError(t, err, `configuration "storage-dev" not found`)
+}
+
+func TestGetItem_Ambiguous(t *testing.T) {
+	items := append(filterTestItems, filterTestItems[0])
+	_, err := getItem(items, "compute-dev")
+	assert.EqualError(t, err, `configuration "compute-dev" is ambiguous, found 2 configurations`)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/file"
+	"github.com/kallangerard/pantalon/git"
+)
+
+// runList implements `pantalon list`, emitting the discovered configurations after filtering.
+func runList(args []string) {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon list - list Terraform root module configurations
+
+Usage:
+  pantalon list [flags]
+
+Flags:
+`)
+		flags.PrintDefaults()
+		fmt.Fprintf(os.Stderr, `
+Examples:
+  pantalon list
+  pantalon list --output-format=yaml
+  pantalon list --changed-dirs='["terraform/compute/environments/dev"]'
+  pantalon list --base-ref=origin/main
+  pantalon list --base-ref=origin/main --removed
+  git diff -z --name-only origin/main... | pantalon list --changed-files=-
+  pantalon list --path-glob='terraform/compute/**'
+  pantalon list --path-glob='terraform/compute/**' --path-glob='terraform/data/**'
+  pantalon list --selector='tier=prod,team in (platform,data)'
+  pantalon list --waves
+  pantalon list --preset=prod
+`)
+	}
+	help := flags.Bool("help", false, "Show help")
+	outputFormat := flags.String("output-format", defaultOutputFormat(), "Output format: json or yaml")
+	opts := filterOptions{stdin: os.Stdin}
+	opts.register(flags)
+	waves := flags.Bool("waves", false, "Group output into dependency waves, as an object keyed by wave index")
+	removed := flags.Bool("removed", false, "Output the configurations removed since the merge-base of --base-ref and --head-ref instead of the current configurations")
+	preset := flags.String("preset", "", "Name of a filter preset of the .pantalon.yaml; filter flags given take precedence")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+	opts.nested = search.options(false).Nested
+	// The globs of a preset are relative to the repository already, so only those given as flags are re-based.
+	opts.globs = argPaths(opts.globs)
+
+	if *help {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	if *preset != "" {
+		p, ok := repository.Spec.Presets[*preset]
+		if !ok {
+			log.Fatalf("Unknown preset %q", *preset)
+		}
+		set := make(map[string]bool)
+		flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		opts.applyPreset(p, set)
+	}
+
+	if *removed {
+		if opts.baseRef == "" {
+			log.Fatalf("--removed requires --base-ref")
+		}
+		if *waves {
+			log.Fatalf("--waves cannot be used with --removed")
+		}
+
+		items, err := removedItems(search.options(false), opts)
+		if err != nil {
+			log.Fatalf("Error listing removed configurations: %v", err)
+		}
+		output(items, *outputFormat)
+		return
+	}
+
+	unfilteredItems, err := discover(search.options(false))
+	if err != nil {
+		log.Fatalf("Error discovering configurations: %v", err)
+	}
+
+	items, err := filterItems(unfilteredItems, opts)
+	if err != nil {
+		log.Fatalf("Error filtering items: %v", err)
+	}
+
+	if *waves {
+		grouped, err := groupWaves(unfilteredItems, items)
+		if err != nil {
+			log.Fatalf("Error grouping waves: %v", err)
+		}
+		output(grouped, *outputFormat)
+		return
+	}
+
+	output(items, *outputFormat)
+}
+
+// removedItems returns the configurations which existed at the merge-base of --base-ref and --head-ref but no longer
+// exist at --head-ref, filtered by --path-glob and --selector.
+func removedItems(search file.Options, opts filterOptions) ([]api.ConfigurationItem, error) {
+	removed, err := search.Removed(opts.baseRef, opts.headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := marshalItems(removed)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterItems(items, filterOptions{globs: opts.globs, selectors: opts.selectors})
+}
+
+// filterOptions holds the filters applied to discovered configurations.
+type filterOptions struct {
+	changedDirsJson  string
+	changedDirsMatch string
+	changedFiles     string
+	baseRef          string
+	headRef          string
+	globs            []string
+	selectors        []string
+	// nested attributes a change within nested configurations to the deepest only, as with --nested.
+	nested bool
+
+	// stdin is read when changedFiles is "-".
+	stdin io.Reader
+}
+
+// register adds the filter flags to flags.
+func (o *filterOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.changedDirsJson, "changed-dirs", "", `JSON array of changed directories; filters output to matching configs (e.g. '["terraform/compute/environments/dev"]')`)
+	flags.StringVar(&o.changedDirsMatch, "changed-dirs-match", "ancestors,descendants", "How changed directories match configuration directories, used with --changed-dirs and --base-ref: comma separated list of exact, ancestors and descendants")
+	flags.StringVar(&o.changedFiles, "changed-files", "", "Changed files as a JSON array, newline or NUL separated list, or - to read from stdin; filters output to configs owning them")
+	flags.StringVar(&o.baseRef, "base-ref", "", "Git ref to compare against; filters output to configs changed since the merge-base of --base-ref and --head-ref")
+	flags.StringVar(&o.headRef, "head-ref", "HEAD", "Git ref containing the changes, used with --base-ref")
+	flags.Var((*stringList)(&o.globs), "path-glob", "Doublestar glob pattern to filter configurations by directory path (repeatable, OR logic)")
+	flags.Var((*stringList)(&o.selectors), "selector", "Label selector to filter configurations by metadata.labels, e.g. 'tier=prod,team in (platform,data)' (repeatable, OR logic)")
+}
+
+// applyPreset sets the filters of preset, except those in set, the names of the flags given on the command line.
+func (o *filterOptions) applyPreset(preset api.FilterPreset, set map[string]bool) {
+	if !set["path-glob"] && len(preset.PathGlobs) > 0 {
+		o.globs = preset.PathGlobs
+	}
+	if !set["selector"] && len(preset.Selectors) > 0 {
+		o.selectors = preset.Selectors
+	}
+	if !set["changed-dirs-match"] && preset.ChangedDirsMatch != "" {
+		o.changedDirsMatch = preset.ChangedDirsMatch
+	}
+}
+
+// filterChanged filters items to those changed according to --changed-dirs, --changed-files or --base-ref.
+// If none are set all items are returned.
+func (o filterOptions) filterChanged(items []api.ConfigurationItem) ([]api.ConfigurationItem, error) {
+	sources := 0
+	for _, v := range []string{o.changedDirsJson, o.changedFiles, o.baseRef} {
+		if v != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return nil, errors.New("only one of --changed-dirs, --changed-files or --base-ref may be used")
+	}
+
+	switch {
+	case o.changedDirsJson != "":
+		changedDirs, err := api.UnmarshalChangedFileJson([]byte(o.changedDirsJson))
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling changed dirs: %w", err)
+		}
+		return o.filterChangedDirs(items, argPaths(changedDirs))
+	case o.baseRef != "":
+		changedDirs, err := git.ChangedDirs(o.baseRef, o.headRef)
+		if err != nil {
+			return nil, fmt.Errorf("error reading changes from git: %w", err)
+		}
+		return o.filterChangedDirs(items, changedDirs)
+	case o.changedFiles != "":
+		input := []byte(o.changedFiles)
+		if o.changedFiles == "-" {
+			var err error
+			input, err = io.ReadAll(o.stdin)
+			if err != nil {
+				return nil, fmt.Errorf("error reading changed files from stdin: %w", err)
+			}
+		}
+		changedFiles, err := api.UnmarshalChangedFiles(input)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshaling changed files: %w", err)
+		}
+		items, err = file.Options{Nested: o.nested}.ChangedFilePaths(items, argPaths(changedFiles))
+		if err != nil {
+			return nil, fmt.Errorf("error filtering changed files: %w", err)
+		}
+		return items, nil
+	}
+
+	return items, nil
+}
+
+func (o filterOptions) filterChangedDirs(items []api.ConfigurationItem, changedDirs []string) ([]api.ConfigurationItem, error) {
+	match := file.MatchAll
+	if o.changedDirsMatch != "" {
+		var err error
+		match, err = file.ParseDirMatch(o.changedDirsMatch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := file.Options{Nested: o.nested}.ChangedDirs(items, changedDirs, match)
+	if err != nil {
+		return nil, fmt.Errorf("error filtering changed files: %w", err)
+	}
+	return items, nil
+}
+
+func filterItems(unfilteredItems []api.ConfigurationItem, opts filterOptions) ([]api.ConfigurationItem, error) {
+	items, err := opts.filterChanged(unfilteredItems)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.globs) > 0 {
+		items, err = file.GlobFilter(items, opts.globs)
+		if err != nil {
+			return nil, fmt.Errorf("error filtering by path glob: %w", err)
+		}
+	}
+
+	if len(opts.selectors) > 0 {
+		items, err = file.SelectorFilter(items, opts.selectors)
+		if err != nil {
+			return nil, fmt.Errorf("error filtering by selector: %w", err)
+		}
+	}
+
+	return items, nil
+}
+
+// groupWaves groups the selected items into dependency waves keyed by wave index.
+//
+// Waves are computed from all items so transitive dependencies through unselected items are respected.
+// Waves left empty by filtering are dropped and the remaining waves are renumbered.
+func groupWaves(allItems []api.ConfigurationItem, selectedItems []api.ConfigurationItem) (yaml.MapSlice, error) {
+	allWaves, err := api.GroupWaves(allItems)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(selectedItems))
+	for _, item := range selectedItems {
+		selected[item.Path+"\x00"+item.Name] = true
+	}
+
+	result := yaml.MapSlice{}
+	for _, wave := range allWaves {
+		items := make([]api.ConfigurationItem, 0)
+		for _, item := range wave {
+			if selected[item.Path+"\x00"+item.Name] {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			result = append(result, yaml.MapItem{Key: strconv.Itoa(len(result)), Value: items})
+		}
+	}
+	return result, nil
+}
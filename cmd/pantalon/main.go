@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/goccy/go-yaml"
 
@@ -12,106 +14,247 @@ import (
 	"github.com/kallangerard/pantalon/file"
 )
 
-// pathGlobs implements flag.Value for a repeatable --path-glob flag.
-type pathGlobs []string
+// stringList implements flag.Value for repeatable flags such as --path-glob and --selector.
+type stringList []string
 
-func (p *pathGlobs) String() string { return fmt.Sprintf("%v", *p) }
-func (p *pathGlobs) Set(v string) error {
+func (p *stringList) String() string { return fmt.Sprintf("%v", *p) }
+func (p *stringList) Set(v string) error {
 	*p = append(*p, v)
 	return nil
 }
 
-func init() {
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, `pantalon - identify Terraform root module configurations for CI/CD pipelines
+// commands maps each subcommand name to its implementation, which receives the arguments after the name.
+var commands = map[string]func(args []string){
+	"list":     runList,
+	"validate": runValidate,
+	"get":      runGet,
+	"which":    runWhich,
+	"diff":     runDiff,
+	"schema":   runSchema,
+	"migrate":  runMigrate,
+	"fmt":      runFmt,
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, `pantalon - identify Terraform root module configurations for CI/CD pipelines
 
 Walks the repository from the current directory, finds pantalon.yaml marker
 files, and emits a machine-readable list of Terraform root modules suitable
 for use in GitHub Actions job matrices or other CI/CD tooling.
 
+If a .pantalon.yaml is found in the current directory or a parent, up to the
+root of the git repository, pantalon runs from its directory and uses its
+search roots, exclusions and defaults. Paths given as arguments or flags are
+still relative to the current directory.
+
 Usage:
-  pantalon [flags]
+  pantalon <command> [flags]
+  pantalon [flags]              same as pantalon list
 
-Flags:
-`)
-		flag.PrintDefaults()
-		fmt.Fprintf(os.Stderr, `
-Examples:
-  pantalon
-  pantalon --output-format=yaml
-  pantalon --changed-dirs='["terraform/compute/environments/dev"]'
-  pantalon --path-glob='terraform/compute/**'
-  pantalon --path-glob='terraform/compute/**' --path-glob='terraform/data/**'
+Commands:
+  list       List configurations, optionally filtered
+  validate   Check every pantalon.yaml and report all errors
+  get        Print a single configuration by name
+  which      Print the configuration owning a file or directory
+  diff       Compare the configurations between two git refs
+  schema     Print the JSON Schema for pantalon.yaml
+  migrate    Rewrite every pantalon.yaml as the latest apiVersion
+  fmt        Rewrite every pantalon.yaml in canonical form
+
+Run 'pantalon <command> --help' for the flags of each command.
 `)
-	}
 }
 
 func main() {
-	help := flag.Bool("help", false, "Show help")
-	outputFormat := flag.String("output-format", "json", "Output format: json or yaml")
-	changedDirsJson := flag.String("changed-dirs", "", `JSON array of changed directories; filters output to matching configs (e.g. '["terraform/compute/environments/dev"]')`)
-	var globs pathGlobs
-	flag.Var(&globs, "path-glob", "Doublestar glob pattern to filter configurations by directory path (repeatable, OR logic)")
-	flag.Parse()
+	if len(os.Args) < 2 {
+		if err := loadRepository(); err != nil {
+			log.Fatalf("Error reading %s: %v", file.RepositoryFile, err)
+		}
+		runList(nil)
+		return
+	}
 
-	if *help {
-		flag.Usage()
-		os.Exit(0)
+	name := os.Args[1]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage()
+		return
 	}
 
-	configurations, err := file.Search()
-	if err != nil {
-		log.Fatalf("Error listing configurations: %v", err)
+	if err := loadRepository(); err != nil {
+		log.Fatalf("Error reading %s: %v", file.RepositoryFile, err)
+	}
+
+	if command, ok := commands[name]; ok {
+		command(os.Args[2:])
+		return
 	}
 
-	unfilteredItems, err := api.MarshalItems(configurations)
+	// Flags without a command are passed to list, for compatibility with earlier versions.
+	runList(os.Args[1:])
+}
+
+// repository is the .pantalon.yaml of the repository, if any.
+var repository api.RepositoryConfiguration
+
+// invocationDir is the working directory pantalon was run from, before changing to the directory of repository.
+// Paths given as arguments or flags are relative to it.
+var invocationDir string
+
+// loadRepository reads the .pantalon.yaml above the working directory, if any, and changes to its directory so every
+// path is relative to it.
+func loadRepository() error {
+	cwd, err := os.Getwd()
 	if err != nil {
-		log.Fatalf("Error marshaling items: %v", err)
+		return err
+	}
+	invocationDir = cwd
+
+	cfg, ok, err := file.FindRepository(cwd)
+	if err != nil || !ok {
+		return err
 	}
+	repository = cfg
+	// The path is recorded in the ContextSources of items, relative to the directory of the repository like those of
+	// pantalon.defaults.yaml files.
+	repository.Path = filepath.Base(cfg.Path)
+	return os.Chdir(cfg.Dir)
+}
 
-	items, err := filterItems(unfilteredItems, *changedDirsJson, globs)
+// argPath returns a path or glob pattern given as an argument or flag relative to the working directory, which differs
+// from the directory pantalon was run from when a .pantalon.yaml was found in a parent.
+func argPath(p string) string {
+	if filepath.IsAbs(p) || invocationDir == "" {
+		return p
+	}
+	cwd, err := os.Getwd()
+	if err != nil || cwd == invocationDir {
+		return p
+	}
+	rel, err := filepath.Rel(cwd, filepath.Join(invocationDir, p))
 	if err != nil {
-		log.Fatalf("Error filtering items: %v", err)
+		return p
 	}
+	return rel
+}
 
-	switch *outputFormat {
-	case "json":
-		outputJson(items)
-	case "yaml":
-		outputYaml(items)
-	default:
-		log.Fatalf("Unsupported output format: %s", *outputFormat)
+// argPaths returns each path given as an argument or flag relative to the working directory.
+func argPaths(paths []string) []string {
+	result := make([]string, 0, len(paths))
+	for _, p := range paths {
+		result = append(result, argPath(p))
 	}
+	return result
 }
 
-func filterItems(unfilteredItems []api.ConfigurationItem, changedDirsJson string, globs []string) ([]api.ConfigurationItem, error) {
-	var items []api.ConfigurationItem
+// searchFlags holds the flags which control where pantalon.yaml files are searched for.
+type searchFlags struct {
+	exclude []string
+	nested  bool
+}
 
-	if changedDirsJson != "" {
-		changedDirs, err := api.UnmarshalChangedFileJson([]byte(changedDirsJson))
-		if err != nil {
-			return nil, fmt.Errorf("error unmarshaling changed dirs: %w", err)
-		}
-		items, err = file.ChangedFiles(unfilteredItems, changedDirs)
-		if err != nil {
-			return nil, fmt.Errorf("error filtering changed files: %w", err)
-		}
-	} else {
-		items = unfilteredItems
+// register adds the search flags to flags.
+func (s *searchFlags) register(flags *flag.FlagSet) {
+	flags.Var((*stringList)(&s.exclude), "exclude", "Doublestar glob pattern of directories not searched for pantalon.yaml files, in addition to .gitignore and .pantalonignore files (repeatable)")
+	flags.BoolVar(&s.nested, "nested", repository.Spec.Nested, "Search within the directory of each pantalon.yaml for nested configurations")
+}
+
+// options returns the options for finding pantalon.yaml files within the roots of the repository, excluding the
+// directories excluded by the repository and --exclude.
+func (s searchFlags) options(strict bool) file.Options {
+	return file.Options{
+		Strict:   strict,
+		Roots:    repository.Spec.Roots,
+		Exclude:  append(slices.Clone(repository.Spec.Exclude), argPaths(s.exclude)...),
+		Nested:   s.nested,
+		Defaults: repository.ContextDefaults(),
+	}
+}
+
+// defaultOutputFormat is the default of --output-format, set by the repository.
+func defaultOutputFormat() string {
+	if repository.Spec.OutputFormat != "" {
+		return repository.Spec.OutputFormat
 	}
+	return "json"
+}
+
+// discover finds every configuration, resolving local modules and sorting by dependencies.
+func discover(opts file.Options) ([]api.ConfigurationItem, error) {
+	configurations, err := opts.Search()
+	if err != nil {
+		return nil, fmt.Errorf("error listing configurations: %w", err)
+	}
+	warn(configurations)
 
-	if len(globs) > 0 {
-		var err error
-		items, err = file.GlobFilter(items, globs)
-		if err != nil {
-			return nil, fmt.Errorf("error filtering by path glob: %w", err)
+	return resolveItems(configurations)
+}
+
+// warn prints the warnings of each configuration, such as a deprecated apiVersion, to stderr.
+func warn(configurations []api.TerraformConfiguration) {
+	for _, cfg := range configurations {
+		for _, w := range cfg.Warnings() {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", cfg.Path, w)
 		}
 	}
+}
+
+// resolveItems marshals configurations into items, resolving local modules and sorting by dependencies.
+func resolveItems(configurations []api.TerraformConfiguration) ([]api.ConfigurationItem, error) {
+	items, err := marshalItems(configurations)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling items: %w", err)
+	}
+
+	if err := api.CheckUniqueNames(items); err != nil {
+		return nil, err
+	}
+
+	if err := api.CheckContext(items, repository.Spec.ContextSchema); err != nil {
+		return nil, err
+	}
+
+	items, err = file.LocalModules(items)
+	if err != nil {
+		return nil, fmt.Errorf("error reading local modules: %w", err)
+	}
+
+	if err := api.CheckNesting(items); err != nil {
+		return nil, err
+	}
+
+	items, err = api.SortByDependencies(items)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving dependencies: %w", err)
+	}
 
 	return items, nil
 }
 
-func outputJson(configurations []api.ConfigurationItem) {
+// marshalItems marshals configurations into items, with the environment variables the .pantalon.yaml allows context
+// values to reference.
+func marshalItems(configurations []api.TerraformConfiguration) ([]api.ConfigurationItem, error) {
+	env := make(map[string]string, len(repository.Spec.Env))
+	for _, name := range repository.Spec.Env {
+		if v, ok := os.LookupEnv(name); ok {
+			env[name] = v
+		}
+	}
+	return api.MarshalOptions{Env: env}.MarshalItems(configurations)
+}
+
+func output(v any, outputFormat string) {
+	switch outputFormat {
+	case "json":
+		outputJson(v)
+	case "yaml":
+		outputYaml(v)
+	default:
+		log.Fatalf("Unsupported output format: %s", outputFormat)
+	}
+}
+
+func outputJson(configurations any) {
 	data, err := yaml.MarshalWithOptions(configurations,
 		yaml.JSON(),
 	)
@@ -121,7 +264,7 @@ func outputJson(configurations []api.ConfigurationItem) {
 	fmt.Println(string(data))
 }
 
-func outputYaml(configurations []api.ConfigurationItem) {
+func outputYaml(configurations any) {
 	data, err := yaml.Marshal(configurations)
 	if err != nil {
 		log.Fatalf("Error marshaling yaml: %v", err)
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/goccy/go-yaml"
+
 	"github.com/kallangerard/pantalon/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,9 +18,10 @@ var filterTestItems = []api.ConfigurationItem{
 		Dir:  "terraform/compute/environments/dev",
 	},
 	{
-		Name: "compute-prod",
-		Path: "terraform/compute/environments/prod/pantalon.yaml",
-		Dir:  "terraform/compute/environments/prod",
+		Name:   "compute-prod",
+		Path:   "terraform/compute/environments/prod/pantalon.yaml",
+		Dir:    "terraform/compute/environments/prod",
+		Labels: map[string]string{"tier": "prod"},
 	},
 	{
 		Name: "network-dev",
@@ -25,20 +29,97 @@ var filterTestItems = []api.ConfigurationItem{
 		Dir:  "terraform/network/environments/dev",
 	},
 	{
-		Name: "network-prod",
-		Path: "terraform/network/environments/prod/pantalon.yaml",
-		Dir:  "terraform/network/environments/prod",
+		Name:   "network-prod",
+		Path:   "terraform/network/environments/prod/pantalon.yaml",
+		Dir:    "terraform/network/environments/prod",
+		Labels: map[string]string{"tier": "prod"},
 	},
 }
 
 func TestFilterItems_PathGlobDefined_NoChangedDirs(t *testing.T) {
-	result, err := filterItems(filterTestItems, "", []string{"terraform/compute/**"})
+	result, err := filterItems(filterTestItems, filterOptions{globs: []string{"terraform/compute/**"}})
 	require.NoError(t, err)
 	assert.Equal(t, []api.ConfigurationItem{filterTestItems[0], filterTestItems[1]}, result)
 }
 
 func TestFilterItems_NoChangedDirs_ReturnsAllItems(t *testing.T) {
-	result, err := filterItems(filterTestItems, "", nil)
+	result, err := filterItems(filterTestItems, filterOptions{})
 	require.NoError(t, err)
 	assert.Equal(t, filterTestItems, result)
 }
+
+func TestFilterItems_PathGlobAndSelector(t *testing.T) {
+	result, err := filterItems(filterTestItems, filterOptions{globs: []string{"terraform/compute/**"}, selectors: []string{"tier=prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[1]}, result)
+}
+
+func TestFilterItems_ChangedDirsAndBaseRef_Error(t *testing.T) {
+	_, err := filterItems(filterTestItems, filterOptions{changedDirsJson: `["terraform"]`, baseRef: "main", headRef: "HEAD"})
+	assert.EqualError(t, err, "only one of --changed-dirs, --changed-files or --base-ref may be used")
+}
+
+func TestFilterItems_ChangedDirsMatchExact(t *testing.T) {
+	opts := filterOptions{
+		changedDirsJson:  `["terraform/compute", "terraform/network/environments/dev"]`,
+		changedDirsMatch: "exact",
+	}
+	result, err := filterItems(filterTestItems, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[2]}, result)
+}
+
+func TestFilterItems_ChangedFilesFromStdin(t *testing.T) {
+	stdin := strings.NewReader("terraform/compute/environments/prod/main.tf\x00terraform/network/README.md\x00")
+	result, err := filterItems(filterTestItems, filterOptions{changedFiles: "-", stdin: stdin})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[1]}, result)
+}
+
+func TestFilterItems_ChangedFilesIgnored(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "compute-dev", Dir: "terraform/compute/environments/dev", Ignore: []string{"**/*.md"}},
+		{Name: "compute-prod", Dir: "terraform/compute/environments/prod"},
+	}
+	changedFiles := `["terraform/compute/environments/dev/docs/README.md", "terraform/compute/environments/prod/README.md"]`
+
+	result, err := filterItems(items, filterOptions{changedFiles: changedFiles})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{items[1]}, result)
+}
+
+func TestGroupWaves_DropsEmptyWavesAndRenumbers(t *testing.T) {
+	allItems := []api.ConfigurationItem{
+		{Name: "network-prod", Path: "network/pantalon.yaml"},
+		{Name: "compute-prod", Path: "compute/pantalon.yaml", DependsOn: []string{"network-prod"}},
+		{Name: "data-prod", Path: "data/pantalon.yaml", DependsOn: []string{"compute-prod"}},
+		{Name: "dns", Path: "dns/pantalon.yaml"},
+	}
+	selectedItems := []api.ConfigurationItem{allItems[2], allItems[3]}
+
+	result, err := groupWaves(allItems, selectedItems)
+	require.NoError(t, err)
+	assert.Equal(t, yaml.MapSlice{
+		{Key: "0", Value: []api.ConfigurationItem{allItems[3]}},
+		{Key: "1", Value: []api.ConfigurationItem{allItems[2]}},
+	}, result)
+}
+
+func TestApplyPreset_FlagsTakePrecedence(t *testing.T) {
+	preset := api.FilterPreset{
+		PathGlobs:        []string{"terraform/network/**"},
+		Selectors:        []string{"tier=prod"},
+		ChangedDirsMatch: "exact",
+	}
+
+	opts := filterOptions{globs: []string{"terraform/compute/**"}, changedDirsMatch: "ancestors,descendants"}
+	opts.applyPreset(preset, map[string]bool{"path-glob": true})
+
+	assert.Equal(t, []string{"terraform/compute/**"}, opts.globs)
+	assert.Equal(t, []string{"tier=prod"}, opts.selectors)
+	assert.Equal(t, "exact", opts.changedDirsMatch)
+
+	result, err := filterItems(filterTestItems, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[1]}, result)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// runMigrate implements `pantalon migrate`, rewriting every pantalon.yaml as the latest apiVersion.
+func runMigrate(args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon migrate - rewrite every pantalon.yaml as the latest apiVersion
+
+Rewrites each pantalon.yaml in place as %s, preserving comments and the
+order of keys. Each document of a file is migrated in turn. Given files, only
+those files are migrated. With --dry-run, prints a unified diff of the changes
+instead.
+
+Usage:
+  pantalon migrate [flags] [file...]
+
+Flags:
+`, api.LatestVersion)
+		flags.PrintDefaults()
+	}
+	dryRun := flags.Bool("dry-run", false, "Print a unified diff of the changes without writing them")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	paths := argPaths(flags.Args())
+	if len(paths) == 0 {
+		var err error
+		paths, err = search.options(false).Paths()
+		if err != nil {
+			log.Fatalf("Error listing configurations: %v", err)
+		}
+	}
+
+	var failed bool
+	for _, path := range paths {
+		diff, err := migrateFile(path, *dryRun)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		if *dryRun {
+			fmt.Print(diff)
+		} else if diff != "" {
+			fmt.Fprintf(os.Stderr, "migrated %s\n", path)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// migrateFile migrates the pantalon.yaml at path, returning a unified diff of the changes. With dryRun, the file is
+// not written.
+func migrateFile(path string, dryRun bool) (string, error) {
+	return rewriteFile(path, api.Migrate, !dryRun)
+}
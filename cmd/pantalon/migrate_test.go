@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const v1alpha1Yaml = `apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: compute-prod
+context:
+  foo: bar
+`
+
+func TestMigrateFile(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(t.TempDir())
+
+	require.NoError(t, os.MkdirAll("compute", 0o755))
+	path := filepath.Join("compute", "pantalon.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(v1alpha1Yaml), 0o644))
+
+	expectedDiff := `--- a/compute/pantalon.yaml
++++ b/compute/pantalon.yaml
+@@ -1,6 +1,7 @@
+-apiVersion: pantalon.kallan.dev/v1alpha1
++apiVersion: pantalon.kallan.dev/v1beta1
+ kind: TerraformConfiguration
+ metadata:
+   name: compute-prod
+-context:
+-  foo: bar
++spec:
++  context:
++    foo: bar
+`
+
+	diff, err := migrateFile(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, expectedDiff, diff)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1Yaml, string(b), "dry run must not write the file")
+
+	diff, err = migrateFile(path, false)
+	require.NoError(t, err)
+	assert.Equal(t, expectedDiff, diff)
+
+	b, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "apiVersion: pantalon.kallan.dev/v1beta1")
+
+	diff, err = migrateFile(path, false)
+	require.NoError(t, err)
+	assert.Empty(t, diff, "migrating again is a no-op")
+}
+
+func TestMigrateFile_Invalid(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "invalid-dir"))
+
+	_, err = migrateFile(filepath.Join("c", "pantalon.yaml"), true)
+	assert.ErrorContains(t, err, "c/pantalon.yaml:5:9: invalid metadata.name")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRepository(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(originalCwd)
+		repository = api.RepositoryConfiguration{}
+		invocationDir = ""
+	})
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "repository-dir", "terraform", "app"))
+
+	require.NoError(t, loadRepository())
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, repository.Dir, cwd)
+	assert.Equal(t, "yaml", defaultOutputFormat())
+	assert.Equal(t, filepath.Join("terraform", "app", "pantalon.yaml"), argPath("pantalon.yaml"))
+	assert.Equal(t, "terraform", argPath(".."))
+
+	items, err := discover(searchFlags{}.options(false))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "app", items[0].Name)
+	assert.Equal(t, map[string]any{"region": "us-east-1", "team": "data"}, items[0].Context)
+
+	assert.Empty(t, validate(searchFlags{}.options(true), nil))
+}
+
+func TestLoadRepository_PathFlagsFromSubdirectory(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(originalCwd)
+		repository = api.RepositoryConfiguration{}
+		invocationDir = ""
+	})
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "repository-dir", "terraform"))
+
+	require.NoError(t, loadRepository())
+
+	items, err := discover(searchFlags{}.options(false))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	tests := []struct {
+		name string
+		opts filterOptions
+	}{
+		{name: "changed dirs", opts: filterOptions{changedDirsJson: `["app"]`, changedDirsMatch: "exact"}},
+		{name: "changed files", opts: filterOptions{changedFiles: "app/main.tf"}},
+		{name: "path glob", opts: filterOptions{globs: argPaths([]string{"app"})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := filterItems(items, tt.opts)
+			require.NoError(t, err)
+			require.Len(t, filtered, 1)
+			assert.Equal(t, filepath.Join("terraform", "app"), filtered[0].Dir)
+		})
+	}
+
+	items, err = discover(searchFlags{exclude: []string{"app"}}.options(false))
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestArgPath_NoRepository(t *testing.T) {
+	assert.Equal(t, "pantalon.yaml", argPath("pantalon.yaml"))
+	assert.Equal(t, "/tmp/pantalon.yaml", argPath("/tmp/pantalon.yaml"))
+}
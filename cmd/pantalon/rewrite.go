@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// rewriteFile applies rewrite to the pantalon.yaml at path, returning a unified diff of the changes, or "" if there
+// are none. The file is only written if write is true.
+func rewriteFile(path string, rewrite func([]byte) ([]byte, error), write bool) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	after, err := rewrite(before)
+	if err != nil {
+		return "", api.WithPath(err, path)
+	}
+	if bytes.Equal(before, after) {
+		return "", nil
+	}
+
+	if write {
+		if err := os.WriteFile(path, after, info.Mode().Perm()); err != nil {
+			return "", err
+		}
+	}
+	return unifiedDiff("a/"+path, "b/"+path, string(before), string(after)), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// runSchema implements `pantalon schema`, printing the JSON Schema for pantalon.yaml.
+func runSchema(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon schema - print the JSON Schema for pantalon.yaml
+
+The schema covers every apiVersion, unless --api-version is given. It can be
+used by editors through yaml-language-server, or to check pantalon.yaml files
+with any JSON Schema validator.
+
+Usage:
+  pantalon schema [flags]
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	outputFormat := flags.String("output-format", defaultOutputFormat(), "Output format: json or yaml")
+	apiVersion := flags.String("api-version", "", fmt.Sprintf("Only include the apiVersion %s", strings.Join(api.ApiVersions(), " or ")))
+	flags.Parse(args)
+
+	if *apiVersion == "" {
+		output(api.Schema(), *outputFormat)
+		return
+	}
+	schema, err := api.SchemaFor(*apiVersion)
+	if err != nil {
+		log.Fatal(err)
+	}
+	output(schema, *outputFormat)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unifiedContext is the number of unchanged lines shown around each change.
+const unifiedContext = 3
+
+// unifiedDiff returns the changes from a to b as a unified diff, or "" if they're equal.
+//
+// The files are small, so the longest common subsequence of their lines is found directly.
+func unifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	from := splitLines(a)
+	to := splitLines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type edit struct {
+		op   byte
+		line string
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			edits = append(edits, edit{' ', from[i]})
+			i++
+			j++
+		case j < len(to) && (i == len(from) || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{'+', to[j]})
+			j++
+		default:
+			edits = append(edits, edit{'-', from[i]})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+
+	// fromLine and toLine are the 1-based lines of edits[k] in each file.
+	fromLine, toLine := 1, 1
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			fromLine++
+			toLine++
+			k++
+			continue
+		}
+
+		// A hunk starts before the change and continues until a run of unchanged lines separates it from the next.
+		start := max(0, k-unifiedContext)
+		end := k
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].op == ' ' {
+				run++
+			}
+			if run == len(edits) || run-end > 2*unifiedContext {
+				end = min(run, end+unifiedContext)
+				break
+			}
+			end = run
+		}
+
+		hunkFrom, hunkTo := fromLine-(k-start), toLine-(k-start)
+		var fromCount, toCount int
+		var body strings.Builder
+		for _, e := range edits[start:end] {
+			if e.op != '+' {
+				fromCount++
+			}
+			if e.op != '-' {
+				toCount++
+			}
+			fmt.Fprintf(&body, "%c%s\n", e.op, e.line)
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n%s", hunkRange(hunkFrom, fromCount), hunkRange(hunkTo, toCount), body.String())
+
+		fromLine += fromCount - (k - start)
+		toLine += toCount - (k - start)
+		k = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start and length of a hunk, where an empty hunk starts at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
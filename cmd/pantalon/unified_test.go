@@ -0,0 +1,105 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected string
+	}{
+		{
+			name:     "equal",
+			a:        "a\nb\n",
+			b:        "a\nb\n",
+			expected: "",
+		},
+		{
+			name: "single change",
+			a:    "a\nb\nc\n",
+			b:    "a\nB\nc\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`,
+		},
+		{
+			name: "insertion into empty file",
+			a:    "",
+			b:    "a\n",
+			expected: `--- a/f
++++ b/f
+@@ -0,0 +1 @@
++a
+`,
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\ntwelve-and-a-half\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++twelve-and-a-half
+`,
+		},
+		{
+			name: "nearby changes share a hunk",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "1\nII\n3\n4\n5\n6\n7\nVIII\n9\n10\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,10 +1,10 @@
+ 1
+-2
++II
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++VIII
+ 9
+ 10
+`,
+		},
+		{
+			name: "move",
+			a:    "a\nb\nc\nd\n",
+			b:    "a\nc\nd\nb\n",
+			expected: `--- a/f
++++ b/f
+@@ -1,4 +1,4 @@
+ a
+-b
+ c
+ d
++b
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unifiedDiff("a/f", "b/f", tt.a, tt.b))
+		})
+	}
+}
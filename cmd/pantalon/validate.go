@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/file"
+)
+
+// runValidate implements `pantalon validate`, checking every pantalon.yaml and exiting non-zero if any are invalid.
+func runValidate(args []string) {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon validate - check every pantalon.yaml
+
+Reads every pantalon.yaml file and resolves the dependencies between them,
+reporting every error found. Exits non-zero if any errors were found.
+
+Deprecated features, such as an old apiVersion, are reported as warnings.
+
+Every file is checked against the JSON Schema printed by pantalon schema, so
+unknown fields, such as a misspelt metdata, and unquoted numbers or booleans
+where a string is expected are rejected, unless --strict=false is given.
+
+With --name-dir-pattern and --name-pattern, the metadata.name of every
+configuration in a matching directory must be derived from its directory, e.g.
+  --name-dir-pattern='terraform/{component}/environments/{env}' --name-pattern='{component}-{env}'
+These default to spec.namePolicy of the .pantalon.yaml.
+
+Given files, only those files are checked, without searching the repository or
+checking names and dependencies across configurations. This suits pre-commit
+hooks which pass the changed files.
+
+Usage:
+  pantalon validate [flags] [file...]
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	strict := flags.Bool("strict", true, "Reject unknown fields and non-string values where a string is expected")
+	nameDirPattern := flags.String("name-dir-pattern", "", "Directory pattern capturing {placeholders} for --name-pattern")
+	namePattern := flags.String("name-pattern", "", "Name expected for configurations matching --name-dir-pattern, e.g. {component}-{env}")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	var policy *api.NamePolicy
+	if *nameDirPattern != "" || *namePattern != "" {
+		if *nameDirPattern == "" || *namePattern == "" {
+			log.Fatal("--name-dir-pattern and --name-pattern must be used together")
+		}
+		p, err := api.NewNamePolicy(argPath(*nameDirPattern), *namePattern)
+		if err != nil {
+			log.Fatal(err)
+		}
+		policy = &p
+	} else {
+		p, err := repository.NamePolicy()
+		if err != nil {
+			log.Fatal(err)
+		}
+		policy = p
+	}
+
+	var errs []error
+	if flags.NArg() > 0 {
+		errs = validateFiles(search.options(*strict), policy, argPaths(flags.Args()))
+	} else {
+		errs = validate(search.options(*strict), policy)
+	}
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// validate returns every error found in the configurations of the repository. If policy is not nil, every
+// metadata.name must also match it.
+func validate(opts file.Options, policy *api.NamePolicy) []error {
+	configurations, err := opts.Validate()
+	errs := unwrapJoined(err)
+	warn(configurations)
+
+	// Names and dependencies can only be checked once every file can be read.
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if policy != nil {
+		items, err := marshalItems(configurations)
+		if err != nil {
+			return []error{err}
+		}
+		errs = unwrapJoined(policy.Check(items))
+	}
+
+	_, err = resolveItems(configurations)
+	return append(errs, unwrapJoined(err)...)
+}
+
+// validateFiles returns every error found in the files at paths, including their context references and values,
+// without checking across configurations.
+func validateFiles(opts file.Options, policy *api.NamePolicy, paths []string) []error {
+	configurations, err := opts.ValidateFiles(paths)
+	errs := unwrapJoined(err)
+	warn(configurations)
+
+	items, err := marshalItems(configurations)
+	if err != nil {
+		return append(errs, unwrapJoined(err)...)
+	}
+	errs = append(errs, unwrapJoined(api.CheckContext(items, repository.Spec.ContextSchema))...)
+	if policy != nil {
+		errs = append(errs, unwrapJoined(policy.Check(items))...)
+	}
+	return errs
+}
+
+// unwrapJoined returns the errors joined by errors.Join, recursively, or err itself.
+func unwrapJoined(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, unwrapJoined(e)...)
+	}
+	return errs
+}
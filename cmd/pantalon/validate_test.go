@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_InvalidFiles(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "invalid-dir"))
+
+	errs := validate(file.Options{Strict: true}, nil)
+
+	assert.Len(t, errs, 2)
+}
+
+func TestValidate_ValidFiles(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "sibling-dir"))
+
+	assert.Empty(t, validate(file.Options{Strict: true}, nil))
+}
+
+func TestValidate_DuplicateNames(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "duplicate-dir"))
+
+	errs := validate(file.Options{Strict: true}, nil)
+
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], api.ErrDuplicateName)
+	assert.EqualError(t, errs[0], `duplicate metadata.name "duplicate-dir": a/pantalon.yaml, b/pantalon.yaml`)
+}
+
+func TestValidate_NamePolicy(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "sibling-dir"))
+
+	policy, err := api.NewNamePolicy("{dir}", "sibling-dir-{dir}")
+	require.NoError(t, err)
+	assert.Empty(t, validate(file.Options{Strict: true}, &policy))
+
+	policy, err = api.NewNamePolicy("{dir}", "{dir}-sibling-dir")
+	require.NoError(t, err)
+	errs := validate(file.Options{Strict: true}, &policy)
+	require.Len(t, errs, 3)
+	assert.EqualError(t, errs[0], `a/pantalon.yaml: metadata.name does not match name pattern "{dir}-sibling-dir": expected "a-sibling-dir", got "sibling-dir-a"`)
+}
+
+func TestValidateFiles(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "..", "testdata", "terraform", "invalid-dir"))
+
+	assert.Empty(t, validateFiles(file.Options{Strict: true}, nil, []string{"a/pantalon.yaml"}))
+
+	errs := validateFiles(file.Options{Strict: true}, nil, []string{"a/pantalon.yaml", "c/pantalon.yaml"})
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "c/pantalon.yaml")
+}
+
+func TestValidateFiles_UndefinedReference(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(t.TempDir())
+
+	require.NoError(t, os.WriteFile("pantalon.yaml", []byte(`apiVersion: pantalon.kallan.dev/v1beta1
+kind: TerraformConfiguration
+metadata:
+  name: compute
+spec:
+  context:
+    project: pantalon-${labels.env}
+`), 0o644))
+
+	errs := validateFiles(file.Options{Strict: true}, nil, []string{"pantalon.yaml"})
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], api.ErrUndefinedReference)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/file"
+)
+
+// runWhich implements `pantalon which <path>`, printing the configurations owning a file or directory.
+func runWhich(args []string) {
+	flags := flag.NewFlagSet("which", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, `pantalon which - print the configurations owning a file or directory
+
+The owning configurations are those with the deepest directory containing the
+path. This is a list, since a pantalon.yaml with several documents or a matrix
+is more than one configuration.
+
+Usage:
+  pantalon which [flags] <path>
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	outputFormat := flags.String("output-format", defaultOutputFormat(), "Output format: json or yaml")
+	var search searchFlags
+	search.register(flags)
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	items, err := discover(search.options(false))
+	if err != nil {
+		log.Fatalf("Error discovering configurations: %v", err)
+	}
+
+	owners, err := whichItems(items, argPath(flags.Arg(0)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	output(owners, *outputFormat)
+}
+
+// whichItems returns the items owning path. Absolute paths are made relative to the current working directory.
+func whichItems(items []api.ConfigurationItem, path string) ([]api.ConfigurationItem, error) {
+	if filepath.IsAbs(path) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		path, err = filepath.Rel(cwd, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	owners, ok := file.Owner(items, filepath.Clean(path))
+	if !ok {
+		return nil, fmt.Errorf("no configuration owns %q", path)
+	}
+	return owners, nil
+}
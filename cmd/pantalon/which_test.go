@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhichItems_File(t *testing.T) {
+	owners, err := whichItems(filterTestItems, "terraform/network/environments/prod/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[3]}, owners)
+}
+
+func TestWhichItems_AbsolutePath(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	owners, err := whichItems(filterTestItems, filepath.Join(cwd, "terraform", "compute", "environments", "dev", "modules"))
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{filterTestItems[0]}, owners)
+}
+
+func TestWhichItems_SharedDir(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "compute-eu", Dir: "terraform/compute"},
+		{Name: "compute-us", Dir: "terraform/compute"},
+		{Name: "network", Dir: "terraform/network"},
+	}
+
+	owners, err := whichItems(items, "terraform/compute/main.tf")
+	require.NoError(t, err)
+	assert.Equal(t, items[:2], owners)
+}
+
+func TestWhichItems_NotOwned(t *testing.T) {
+	_, err := whichItems(filterTestItems, "terraform/network/README.md")
+	assert.EqualError(t, err, `no configuration owns "terraform/network/README.md"`)
+}
@@ -1,23 +1,225 @@
 package file
 
 import (
+	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/kallangerard/pantalon/api"
 )
 
+// DirMatch controls which changed directories match a configuration directory, in addition to an exact match.
+// Matching is always on whole path segments, so `a/dev-2` never matches `a/dev`.
+type DirMatch uint8
+
+const (
+	// MatchDescendants matches a changed directory within a configuration directory, e.g. `a/b/modules` for `a/b`.
+	MatchDescendants DirMatch = 1 << iota
+	// MatchAncestors matches a changed directory above a configuration directory, e.g. `a` or `.` for `a/b`.
+	MatchAncestors
+
+	// MatchExact only matches a changed directory equal to a configuration directory.
+	MatchExact DirMatch = 0
+	// MatchAll matches descendants and ancestors.
+	MatchAll = MatchDescendants | MatchAncestors
+)
+
+// ParseDirMatch parses a comma separated list of `exact`, `descendants` and `ancestors`.
+func ParseDirMatch(s string) (DirMatch, error) {
+	match := MatchExact
+	for _, v := range strings.Split(s, ",") {
+		switch strings.TrimSpace(v) {
+		case "exact":
+		case "descendants":
+			match |= MatchDescendants
+		case "ancestors":
+			match |= MatchAncestors
+		default:
+			return MatchExact, fmt.Errorf("invalid directory match %q: must be exact, descendants or ancestors", v)
+		}
+	}
+	return match, nil
+}
+
 // ChangedFiles filters the list of unfiltered configuration files based on the list of changed directories.
+//
+// A configuration has changed if a changed directory is within or above its directory, or within or above
+// any of the local modules it consumes.
 func ChangedFiles(allItems []api.ConfigurationItem, changedDirs []string) ([]api.ConfigurationItem, error) {
-	filteredCfgs := make([]api.ConfigurationItem, 0)
+	return Options{}.ChangedFiles(allItems, changedDirs)
+}
+
+// ChangedFiles filters the list of unfiltered configuration files based on the list of changed directories, as
+// ChangedDirs does with MatchAll.
+func (o Options) ChangedFiles(allItems []api.ConfigurationItem, changedDirs []string) ([]api.ConfigurationItem, error) {
+	return o.ChangedDirs(allItems, changedDirs, MatchAll)
+}
+
+// ChangedDirs filters the list of configurations to those whose directory, or the directory of any local
+// module they consume, matches a changed directory.
+func ChangedDirs(allItems []api.ConfigurationItem, changedDirs []string, match DirMatch) ([]api.ConfigurationItem, error) {
+	return Options{}.ChangedDirs(allItems, changedDirs, match)
+}
+
+// ChangedDirs filters the list of configurations to those whose directory, or the directory of any local
+// module they consume, matches a changed directory.
+//
+// If o.Nested is set, a changed directory within nested configurations only matches the deepest, as it owns its
+// directory.
+func (o Options) ChangedDirs(allItems []api.ConfigurationItem, changedDirs []string, match DirMatch) ([]api.ConfigurationItem, error) {
+	trie := newItemTrie(allItems)
+	changed := make([]bool, len(allItems))
+	mark := func(item int) { changed[item] = true }
+
+	for _, dir := range changedDirs {
+		var nodes []ownerNode
+		node := trie.walk(dir, func(node *dirTrie, rest []string) {
+			if len(rest) == 0 || match&MatchDescendants != 0 {
+				nodes = append(nodes, newOwnerNode(node, dir, rest))
+			}
+		})
+		for _, owner := range pathOwners(allItems, nodes, o.Nested) {
+			mark(owner.item)
+		}
+
+		if node != nil && match&MatchAncestors != 0 {
+			node.collect(mark)
+		}
+	}
+
+	return selectItems(allItems, changed), nil
+}
+
+// ChangedFilePaths filters the list of configurations to those owning at least one of the changed files.
+//
+// A configuration owns a file within its directory, or within any of the local modules it consumes. Files matching
+// one of the configuration's ignore patterns, relative to the owning directory, are disregarded.
+func ChangedFilePaths(allItems []api.ConfigurationItem, changedFiles []string) ([]api.ConfigurationItem, error) {
+	return Options{}.ChangedFilePaths(allItems, changedFiles)
+}
+
+// ChangedFilePaths filters the list of configurations to those owning at least one of the changed files.
+//
+// If o.Nested is set, a file within the directory of a nested configuration is only owned by the nested
+// configuration, and the configurations consuming it as a local module.
+func (o Options) ChangedFilePaths(allItems []api.ConfigurationItem, changedFiles []string) ([]api.ConfigurationItem, error) {
+	trie := newItemTrie(allItems)
+	changed := make([]bool, len(allItems))
+
+	for _, file := range changedFiles {
+		var nodes []ownerNode
+		trie.walk(file, func(node *dirTrie, rest []string) {
+			if len(rest) > 0 {
+				nodes = append(nodes, newOwnerNode(node, file, rest))
+			}
+		})
+
+		for _, owner := range pathOwners(allItems, nodes, o.Nested) {
+			if changed[owner.item] {
+				continue
+			}
+			ignored, err := isIgnored(allItems[owner.item].Ignore, path.Join(owner.rest...))
+			if err != nil {
+				return nil, err
+			}
+			changed[owner.item] = !ignored
+		}
+	}
+
+	return selectItems(allItems, changed), nil
+}
+
+// ownerNode is a node of an item trie on the path to a changed file or directory.
+type ownerNode struct {
+	node *dirTrie
+	// dir is the directory of the node.
+	dir string
+	// rest are the segments of the changed path below dir.
+	rest []string
+}
+
+func newOwnerNode(node *dirTrie, changed string, rest []string) ownerNode {
+	segments := splitDir(changed)
+	return ownerNode{node: node, dir: path.Join(append([]string{"."}, segments[:len(segments)-len(rest)]...)...), rest: rest}
+}
+
+// owner is an item owning a changed path, with the segments of the path below the owning directory.
+type owner struct {
+	item int
+	rest []string
+}
+
+// pathOwners returns the items of nodes which own the changed path. With nested, of the configurations whose
+// directory contains the path only the deepest owns it, as a nested configuration owns its directory. Otherwise, and
+// for every configuration consuming a local module containing the path, each owns it.
+func pathOwners(items []api.ConfigurationItem, nodes []ownerNode, nested bool) []owner {
+	ownsDir := func(item int, n ownerNode) bool {
+		return path.Clean(filepath.ToSlash(items[item].Dir)) == n.dir
+	}
+
+	var result []owner
+	if !nested {
+		for _, n := range nodes {
+			for _, item := range n.node.items {
+				result = append(result, owner{item: item, rest: n.rest})
+			}
+		}
+		return result
+	}
 
-	for _, cfg := range allItems {
-		for _, dir := range changedDirs {
-			if dir == "." || strings.HasPrefix(dir, cfg.Dir) || strings.HasPrefix(cfg.Dir, dir) {
-				filteredCfgs = append(filteredCfgs, cfg)
-				break
+	deepest := ""
+	for _, n := range nodes {
+		for _, item := range n.node.items {
+			if ownsDir(item, n) {
+				deepest = n.dir
 			}
 		}
 	}
 
-	return filteredCfgs, nil
+	for _, n := range nodes {
+		for _, item := range n.node.items {
+			if !ownsDir(item, n) || n.dir == deepest {
+				result = append(result, owner{item: item, rest: n.rest})
+			}
+		}
+	}
+	return result
+}
+
+// newItemTrie indexes each item by its directory and the directories of the local modules it consumes.
+func newItemTrie(items []api.ConfigurationItem) *dirTrie {
+	trie := newDirTrie()
+	for i, item := range items {
+		trie.insert(item.Dir, i)
+		for _, module := range item.Modules {
+			trie.insert(module, i)
+		}
+	}
+	return trie
+}
+
+// selectItems returns the selected items, preserving their order.
+func selectItems(items []api.ConfigurationItem, selected []bool) []api.ConfigurationItem {
+	result := make([]api.ConfigurationItem, 0)
+	for i, item := range items {
+		if selected[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func isIgnored(patterns []string, rel string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := doublestar.Match(pattern, rel)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
 }
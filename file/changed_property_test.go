@@ -0,0 +1,76 @@
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"pgregory.net/rapid"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genTreeDirPath produces a directory path from a small alphabet of segments, so generated
+// configuration and changed directories frequently overlap.
+func genTreeDirPath() *rapid.Generator[string] {
+	return rapid.Custom(func(t *rapid.T) string {
+		depth := rapid.IntRange(0, 4).Draw(t, "depth")
+		segments := make([]string, depth)
+		for i := range segments {
+			segments[i] = rapid.SampledFrom([]string{"a", "b", "dev", "dev-2"}).Draw(t, "segment")
+		}
+		if depth == 0 {
+			return "."
+		}
+		return strings.Join(segments, "/")
+	})
+}
+
+// isWithinDir is a reference implementation of segment-aware containment, including equality.
+func isWithinDir(dir string, parent string) bool {
+	return parent == "." || dir == parent || strings.HasPrefix(dir, parent+"/")
+}
+
+// deepestDir returns the deepest of dirs containing dir, which owns it.
+func deepestDir(dirs []string, dir string) string {
+	deepest := ""
+	for _, d := range dirs {
+		if isWithinDir(dir, d) && (deepest == "" || isWithinDir(d, deepest)) {
+			deepest = d
+		}
+	}
+	return deepest
+}
+
+// Property: ChangedDirs matches the pairwise segment-aware comparison of every item and changed directory. With
+// Nested, a changed directory within nested configurations only matches the deepest as a descendant.
+func TestChangedDirs_Property_MatchesReference(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		dirs := rapid.SliceOfN(genTreeDirPath(), 0, 8).Draw(t, "dirs")
+		changedDirs := rapid.SliceOfN(genTreeDirPath(), 0, 8).Draw(t, "changedDirs")
+		match := DirMatch(rapid.IntRange(0, int(MatchAll)).Draw(t, "match"))
+		nested := rapid.Bool().Draw(t, "nested")
+
+		items := make([]api.ConfigurationItem, 0, len(dirs))
+		for _, dir := range dirs {
+			items = append(items, api.ConfigurationItem{Name: dir, Dir: dir})
+		}
+
+		expected := make([]api.ConfigurationItem, 0)
+		for _, item := range items {
+			for _, changed := range changedDirs {
+				if item.Dir == changed ||
+					(match&MatchDescendants != 0 && isWithinDir(changed, item.Dir) && (!nested || item.Dir == deepestDir(dirs, changed))) ||
+					(match&MatchAncestors != 0 && isWithinDir(item.Dir, changed)) {
+					expected = append(expected, item)
+					break
+				}
+			}
+		}
+
+		result, err := Options{Nested: nested}.ChangedDirs(items, changedDirs, match)
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+}
@@ -309,3 +309,207 @@ func TestChangedDirs_ChangedDirInsidePantalonCfg(t *testing.T) {
 
 	assert.Equal(t, expectedFilteredCfgs, filteredCfgs)
 }
+
+// If a local module consumed by a Pantalon configuration changed, the configuration should be returned
+func TestChangedDirs_ChangedModule(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{
+			Name:    "compute-dev",
+			Dir:     "compute/environments/dev",
+			Modules: []string{"compute/modules/instance-group/v1"},
+		},
+		{
+			Name:    "compute-prod",
+			Dir:     "compute/environments/prod",
+			Modules: []string{"compute/modules/instance-group/v2", "compute/modules/template/v2"},
+		},
+	}
+
+	filteredCfgs, err := ChangedFiles(items, []string{"compute/modules/template/v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []api.ConfigurationItem{items[1]}, filteredCfgs)
+}
+
+func TestChangedFilePaths_OwnedFiles(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "dev", Dir: "env/dev"},
+		{Name: "dev-2", Dir: "env/dev-2"},
+		{Name: "prod", Dir: "env/prod", Modules: []string{"modules/app"}},
+	}
+
+	filteredCfgs, err := ChangedFilePaths(items, []string{"env/dev/main.tf", "modules/app/main.tf", "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []api.ConfigurationItem{items[0], items[2]}, filteredCfgs)
+}
+
+func TestChangedFilePaths_IgnoredFiles(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "dev", Dir: "env/dev", Ignore: []string{"*.md"}, Modules: []string{"modules/app"}},
+	}
+
+	filteredCfgs, err := ChangedFilePaths(items, []string{"env/dev/README.md", "modules/app/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{}, filteredCfgs)
+
+	filteredCfgs, err = ChangedFilePaths(items, []string{"env/dev/README.md", "env/dev/docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, items, filteredCfgs)
+}
+
+func TestChangedFilePaths_RootConfiguration(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "root", Dir: "."},
+	}
+
+	filteredCfgs, err := ChangedFilePaths(items, []string{"main.tf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, items, filteredCfgs)
+}
+
+// A changed directory must only match whole path segments of a Pantalon configuration directory
+func TestChangedDirs_PartialSegmentDoesNotMatch(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "dev", Dir: "terraform/compute/environments/dev"},
+		{Name: "dev-2", Dir: "terraform/compute/environments/dev-2"},
+	}
+
+	filteredCfgs, err := ChangedFiles(items, []string{"terraform/compute/environments/dev-2/modules"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{items[1]}, filteredCfgs)
+
+	filteredCfgs, err = ChangedFiles(items, []string{"terraform/compute/env"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{}, filteredCfgs)
+}
+
+func TestChangedDirs_MatchModes(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "a", Dir: "a"},
+		{Name: "b", Dir: "a/b"},
+		{Name: "c", Dir: "a/b/c"},
+	}
+
+	tests := []struct {
+		name     string
+		match    DirMatch
+		expected []api.ConfigurationItem
+	}{
+		{name: "Exact", match: MatchExact, expected: []api.ConfigurationItem{items[1]}},
+		{name: "Descendants", match: MatchDescendants, expected: []api.ConfigurationItem{items[0], items[1]}},
+		{name: "Ancestors", match: MatchAncestors, expected: []api.ConfigurationItem{items[1], items[2]}},
+		{name: "All", match: MatchAll, expected: items},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filteredCfgs, err := ChangedDirs(items, []string{"a/b"}, tt.match)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, filteredCfgs)
+		})
+	}
+}
+
+// A change within nested configurations belongs to the deepest, while a change to a module consumed by the parent
+// also changes the parent.
+func TestChangedDirs_NestedConfigurations(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "parent", Dir: "a", Modules: []string{"modules/x"}},
+		{Name: "child", Dir: "a/child"},
+	}
+
+	tests := []struct {
+		dir      string
+		expected []api.ConfigurationItem
+	}{
+		{"a/modules", []api.ConfigurationItem{items[0]}},
+		{"a/child/environments", []api.ConfigurationItem{items[1]}},
+		{"a/child", []api.ConfigurationItem{items[1]}},
+		{"modules/x/files", []api.ConfigurationItem{items[0]}},
+		{"a", items},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			filteredCfgs, err := Options{Nested: true}.ChangedFiles(items, []string{tt.dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.expected, filteredCfgs)
+		})
+	}
+}
+
+func TestChangedFilePaths_NestedConfigurations(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "parent", Dir: "a"},
+		{Name: "child", Dir: "a/child", Ignore: []string{"*.md"}},
+	}
+
+	filteredCfgs, err := Options{Nested: true}.ChangedFilePaths(items, []string{"a/child/main.tf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{items[1]}, filteredCfgs)
+
+	filteredCfgs, err = Options{Nested: true}.ChangedFilePaths(items, []string{"a/child/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{}, filteredCfgs)
+
+	filteredCfgs, err = Options{Nested: true}.ChangedFilePaths(items, []string{"a/main.tf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{items[0]}, filteredCfgs)
+}
+
+// Without nested discovery, a changed directory matches every configuration whose directory contains it.
+func TestChangedDirs_NotNested(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "parent", Dir: "a"},
+		{Name: "child", Dir: "a/child"},
+	}
+
+	filteredCfgs, err := ChangedDirs(items, []string{"a/child/environments"}, MatchDescendants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, items, filteredCfgs)
+
+	filteredCfgs, err = Options{Nested: true}.ChangedDirs(items, []string{"a/child/environments"}, MatchDescendants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []api.ConfigurationItem{items[1]}, filteredCfgs)
+}
+
+func TestParseDirMatch(t *testing.T) {
+	match, err := ParseDirMatch("exact")
+	assert.NoError(t, err)
+	assert.Equal(t, MatchExact, match)
+
+	match, err = ParseDirMatch("descendants, ancestors")
+	assert.NoError(t, err)
+	assert.Equal(t, MatchAll, match)
+
+	_, err = ParseDirMatch("children")
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// DefaultsFile is the name of the files holding the context inherited by the configurations within their directory.
+const DefaultsFile = "pantalon.defaults.yaml"
+
+// readDefaultsFunc returns the content of the file at path, or false if it doesn't exist.
+type readDefaultsFunc func(path string) ([]byte, bool, error)
+
+func readDefaultsFile(path string) ([]byte, bool, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	return b, err == nil, err
+}
+
+// contextDefaults holds the pantalon.defaults.yaml of each directory containing a set of configurations.
+type contextDefaults struct {
+	// outer are merged before the file of any directory.
+	outer []api.ContextDefaults
+	// files are keyed by directory, with no entry for a directory without one.
+	files map[string]api.ContextDefaults
+	// invalid are the directories with a file which couldn't be read.
+	invalid map[string]bool
+}
+
+// readContextDefaults reads the pantalon.defaults.yaml files of the directories containing the configurations at
+// paths, from the working directory down, to be merged after outer. The error of each invalid file is returned once,
+// with the path followed by `@ref` for files read from a git ref.
+func readContextDefaults(paths []string, outer []api.ContextDefaults, read readDefaultsFunc, ref string) (contextDefaults, []error) {
+	d := contextDefaults{outer: outer, files: make(map[string]api.ContextDefaults), invalid: make(map[string]bool)}
+	visited := make(map[string]bool)
+
+	var errs []error
+	for _, path := range paths {
+		for _, dir := range parentDirs(path) {
+			if visited[dir] {
+				continue
+			}
+			visited[dir] = true
+
+			defaultsPath := filepath.Join(dir, DefaultsFile)
+			errPath := defaultsPath
+			if ref != "" {
+				errPath += "@" + ref
+			}
+
+			b, ok, err := read(defaultsPath)
+			if err != nil {
+				d.invalid[dir] = true
+				errs = append(errs, fmt.Errorf("%s: %w", errPath, err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			defaults, err := api.UnmarshalContextDefaults(b)
+			if err != nil {
+				d.invalid[dir] = true
+				errs = append(errs, api.WithPath(err, errPath))
+				continue
+			}
+			defaults.Path = defaultsPath
+			d.files[dir] = defaults
+		}
+	}
+	return d, errs
+}
+
+// apply merges the outer defaults and those of the directories containing cfg into its context. It returns false if
+// any of them is invalid.
+func (d contextDefaults) apply(cfg api.TerraformConfiguration) (api.TerraformConfiguration, bool) {
+	defaults := slices.Clone(d.outer)
+	for _, dir := range parentDirs(cfg.Path) {
+		if d.invalid[dir] {
+			return cfg, false
+		}
+		if file, ok := d.files[dir]; ok {
+			defaults = append(defaults, file)
+		}
+	}
+	return cfg.WithDefaults(defaults), true
+}
+
+// parentDirs returns the directories containing the file at path, from the working directory down. A path outside
+// the working directory has none.
+func parentDirs(path string) []string {
+	dir := filepath.Dir(path)
+	if !filepath.IsLocal(dir) && dir != "." {
+		return nil
+	}
+
+	var dirs []string
+	for ; dir != "."; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, ".")
+	slices.Reverse(dirs)
+	return dirs
+}
@@ -0,0 +1,114 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func contextDefaultsYaml(context string) string {
+	return `---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: ContextDefaults
+spec:
+  context:
+    ` + context + `
+`
+}
+
+func TestValidateFiles_ContextDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pantalon.defaults.yaml":                     contextDefaultsYaml("gcp-project: shared\n    region: eu"),
+		"envs/pantalon.defaults.yaml":                contextDefaultsYaml("region: us"),
+		"envs/dev/pantalon.yaml":                     pantalonYaml("dev"),
+		"envs/prod/pantalon.yaml":                    pantalonYaml("prod"),
+		"envs/prod/pantalon.defaults.yaml":           contextDefaultsYaml("env: default\n    tier: prod"),
+		"other/pantalon.yaml":                        pantalonYaml("other"),
+		"other/unused/deeper/pantalon.defaults.yaml": contextDefaultsYaml("unused: true"),
+	})
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(dir)
+
+	result, err := Validate()
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	assert.Equal(t, map[string]any{"env": "dev", "gcp-project": "shared", "region": "us"}, result[0].Context)
+	assert.Equal(t, map[string]string{
+		"gcp-project": "pantalon.defaults.yaml",
+		"region":      filepath.Join("envs", "pantalon.defaults.yaml"),
+	}, result[0].ContextSources)
+
+	assert.Equal(t, map[string]any{"env": "prod", "gcp-project": "shared", "region": "us", "tier": "prod"}, result[1].Context)
+	assert.Equal(t, filepath.Join("envs", "prod", "pantalon.defaults.yaml"), result[1].ContextSources["tier"])
+	assert.NotContains(t, result[1].ContextSources, "env")
+
+	assert.Equal(t, map[string]any{"env": "other", "gcp-project": "shared", "region": "eu"}, result[2].Context)
+}
+
+func TestValidate_OuterDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"envs/pantalon.defaults.yaml": contextDefaultsYaml("region: us"),
+		"envs/dev/pantalon.yaml":      pantalonYaml("dev"),
+	})
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(dir)
+
+	outer := api.ContextDefaults{
+		Spec: api.ContextDefaultsSpec{Context: map[string]any{"region": "eu", "team": "platform"}},
+		Path: ".pantalon.yaml",
+	}
+	result, err := Options{Defaults: []api.ContextDefaults{outer}}.Validate()
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	assert.Equal(t, map[string]any{"env": "dev", "region": "us", "team": "platform"}, result[0].Context)
+	assert.Equal(t, map[string]string{
+		"region": filepath.Join("envs", "pantalon.defaults.yaml"),
+		"team":   ".pantalon.yaml",
+	}, result[0].ContextSources)
+}
+
+func TestValidateFiles_InvalidContextDefaults(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"envs/pantalon.defaults.yaml": contextDefaultsYaml("region: us") + "  contxt: {}\n",
+		"envs/dev/pantalon.yaml":      pantalonYaml("dev"),
+		"envs/prod/pantalon.yaml":     pantalonYaml("prod"),
+		"other/pantalon.yaml":         pantalonYaml("other"),
+	})
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(dir)
+
+	result, err := Validate()
+	assert.EqualError(t, err, filepath.Join("envs", "pantalon.defaults.yaml")+":7:3: unknown field spec.contxt, did you mean spec.context?")
+	require.Len(t, result, 1)
+	assert.Equal(t, "other", result[0].Metadata.Name)
+}
+
+func TestParentDirs(t *testing.T) {
+	assert.Equal(t, []string{"."}, parentDirs("pantalon.yaml"))
+	assert.Equal(t, []string{".", "a", filepath.Join("a", "b")}, parentDirs(filepath.Join("a", "b", "pantalon.yaml")))
+	assert.Empty(t, parentDirs(filepath.Join("..", "a", "pantalon.yaml")))
+}
@@ -2,34 +2,97 @@ package file
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"github.com/kallangerard/pantalon/api"
 )
 
+// Options control how pantalon.yaml files are read.
+type Options struct {
+	// Strict rejects unknown fields, such as a misspelt `metdata`, and non-string scalars used where a string
+	// is expected.
+	Strict bool
+	// Roots lists the directories searched for pantalon.yaml files, relative to the working directory. The working
+	// directory is searched if empty.
+	Roots []string
+	// Exclude lists doublestar patterns of directories which are not searched, in addition to those ignored by
+	// .gitignore and .pantalonignore files.
+	Exclude []string
+	// Nested continues searching within the directory of a pantalon.yaml, so a configuration may contain others.
+	Nested bool
+	// Defaults are the outermost context defaults, such as the context of the .pantalon.yaml, merged before those of
+	// every pantalon.defaults.yaml.
+	Defaults []api.ContextDefaults
+}
+
+// Search finds and reads every pantalon.yaml file. If any file is invalid, the errors of every invalid file are returned.
 func Search() ([]api.TerraformConfiguration, error) {
-	paths, err := findFiles()
+	return Options{}.Search()
+}
+
+// Search finds and reads every pantalon.yaml file with the options o.
+func (o Options) Search() ([]api.TerraformConfiguration, error) {
+	result, err := o.Validate()
 	if err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	var result []api.TerraformConfiguration
-	for _, path := range paths {
-		tfCfg, err := readFile(path)
-		tfCfg.Path = path
+// Paths returns the path of every pantalon.yaml file, without reading them.
+func Paths() ([]string, error) {
+	return Options{}.Paths()
+}
+
+func findFiles() ([]string, error) {
+	return Options{}.findFiles()
+}
+
+// Paths returns the path of every pantalon.yaml file within o.Roots and not excluded by o.Exclude, without reading
+// them.
+func (o Options) Paths() ([]string, error) {
+	return o.findFiles()
+}
+
+func (o Options) findFiles() ([]string, error) {
+	roots := o.Roots
+	if len(roots) == 0 {
+		roots = []string{"."}
+	}
+
+	ig, err := newIgnorer()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	found := make(map[string]bool)
+	for _, root := range roots {
+		paths, err := o.walk(filepath.Clean(root), ig)
 		if err != nil {
 			return nil, err
 		}
-		result = append(result, tfCfg)
+		// Roots may overlap, so each file is only included once.
+		for _, path := range paths {
+			if !found[path] {
+				found[path] = true
+				result = append(result, path)
+			}
+		}
 	}
 	return result, nil
 }
 
-func findFiles() ([]string, error) {
+// walk returns the pantalon.yaml files within root, without entering directories which are excluded or ignored.
+func (o Options) walk(root string, ig *ignorer) ([]string, error) {
 	var result []string
-	err := filepath.WalkDir(".", func(path string, entry os.DirEntry, err error) error {
+	err := filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
 		if err != nil {
 			log.Println(err)
 			return err
@@ -39,6 +102,22 @@ func findFiles() ([]string, error) {
 			return nil
 		}
 
+		excluded, err := o.excluded(path)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			return filepath.SkipDir
+		}
+
+		ignored, err := ig.ignored(path, true)
+		if err != nil {
+			return err
+		}
+		if ignored {
+			return filepath.SkipDir
+		}
+
 		pantalonPath := filepath.Join(path, "pantalon.yaml")
 
 		_, err = os.Stat(pantalonPath)
@@ -50,7 +129,15 @@ func findFiles() ([]string, error) {
 			return err
 		}
 
+		ignored, err = ig.ignored(pantalonPath, false)
+		if err != nil || ignored {
+			return err
+		}
+
 		result = append(result, pantalonPath)
+		if o.Nested {
+			return nil
+		}
 		return filepath.SkipDir
 	})
 
@@ -60,18 +147,108 @@ func findFiles() ([]string, error) {
 	return result, nil
 }
 
-func readFile(path string) (api.TerraformConfiguration, error) {
+func readFile(path string) ([]api.TerraformConfiguration, error) {
+	return Options{}.readFile(path)
+}
+
+// readFile reads the configuration of each document of the pantalon.yaml at path.
+func (o Options) readFile(path string) ([]api.TerraformConfiguration, error) {
 
 	file, err := os.ReadFile(path)
 
 	if err != nil {
-		return api.TerraformConfiguration{}, err
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
 
 	cfg := api.New()
-	tfCfg, err := cfg.Unmarshal(file)
+	if o.Strict {
+		cfg = api.NewStrict()
+	}
+	tfCfgs, err := cfg.UnmarshalAll(file)
 	if err != nil {
-		return api.TerraformConfiguration{}, err
+		return nil, api.WithPath(err, path)
+	}
+	return tfCfgs, nil
+}
+
+// Validate reads every pantalon.yaml file, returning the valid configurations and the errors of every invalid file.
+func Validate() ([]api.TerraformConfiguration, error) {
+	return Options{}.Validate()
+}
+
+// Validate reads every pantalon.yaml file with the options o, returning the valid configurations and the errors of
+// every invalid file.
+func (o Options) Validate() ([]api.TerraformConfiguration, error) {
+	paths, err := o.findFiles()
+	if err != nil {
+		return nil, err
+	}
+	return o.ValidateFiles(paths)
+}
+
+// excluded reports whether the directory dir, or any of its parents, is one of ignoredDirs or matches a pattern of
+// o.Exclude.
+func (o Options) excluded(dir string) (bool, error) {
+	for dir = filepath.ToSlash(filepath.Clean(dir)); dir != "."; dir = path.Dir(dir) {
+		if ignoredDirs[path.Base(dir)] {
+			return true, nil
+		}
+		for _, pattern := range o.Exclude {
+			matched, err := doublestar.Match(pattern, dir)
+			if err != nil {
+				return false, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// included reports whether the pantalon.yaml at path is within o.Roots and not excluded by o.Exclude.
+func (o Options) included(p string) (bool, error) {
+	dir := path.Dir(filepath.ToSlash(filepath.Clean(p)))
+	excluded, err := o.excluded(dir)
+	if err != nil || excluded {
+		return false, err
+	}
+	if len(o.Roots) == 0 {
+		return true, nil
+	}
+	for _, root := range o.Roots {
+		root = filepath.ToSlash(filepath.Clean(root))
+		if root == "." || dir == root || strings.HasPrefix(dir, root+"/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ValidateFiles reads the pantalon.yaml files at paths with the options o, without searching for other files.
+//
+// The context of o.Defaults and of each pantalon.defaults.yaml in the directories containing a file, from the working
+// directory down, is merged into its context.
+func (o Options) ValidateFiles(paths []string) ([]api.TerraformConfiguration, error) {
+	defaults, errs := readContextDefaults(paths, o.Defaults, readDefaultsFile, "")
+
+	var result []api.TerraformConfiguration
+	for _, path := range paths {
+		tfCfgs, err := o.readFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, tfCfg := range tfCfgs {
+			tfCfg.Path = path
+
+			// The errors of an invalid pantalon.defaults.yaml have already been reported.
+			tfCfg, ok := defaults.apply(tfCfg)
+			if !ok {
+				break
+			}
+			result = append(result, tfCfg)
+		}
 	}
-	return tfCfg, nil
+	return result, errors.Join(errs...)
 }
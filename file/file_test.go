@@ -7,6 +7,7 @@ import (
 
 	"github.com/kallangerard/pantalon/api"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWalkDir(t *testing.T) {
@@ -66,6 +67,22 @@ func TestWalkDir_ChildDirectoriesShouldNotBeSearched(t *testing.T) {
 	assert.Equal(t, expectedPaths, paths)
 }
 
+func TestWalkDir_NestedOptionSearchesChildDirectories(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(path.Join("..", "testdata", "terraform", "nested-dir"))
+
+	paths, err := Options{Nested: true}.Paths()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []string{path.Join("parent", "pantalon.yaml"), path.Join("parent", "child", "pantalon.yaml")}, paths)
+}
+
 func TestWalkDir_SiblingDirectories(t *testing.T) {
 	originalCwd, err := os.Getwd()
 	if err != nil {
@@ -92,16 +109,33 @@ func TestWalkDir_SiblingDirectories(t *testing.T) {
 func TestReadFile_Success(t *testing.T) {
 	path := path.Join("..", "testdata", "terraform", "single-dir", "pantalon.yaml")
 
-	cfg, err := readFile(path)
+	cfgs, err := readFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	require.Len(t, cfgs, 1)
+	cfg := cfgs[0]
+	assert.Nil(t, cfg.Document)
 	assert.Equal(t, "pantalon.kallan.dev/v1alpha1", cfg.ApiVersion)
 	assert.Equal(t, "TerraformConfiguration", cfg.Kind)
 	assert.Equal(t, "single-dir", cfg.Metadata.Name)
 }
 
+func TestReadFile_MultipleDocuments(t *testing.T) {
+	path := path.Join("..", "testdata", "terraform", "multi-document", "pantalon.yaml")
+
+	cfgs, err := readFile(path)
+	require.NoError(t, err)
+
+	require.Len(t, cfgs, 2)
+	assert.Equal(t, "compute-eu", cfgs[0].Metadata.Name)
+	assert.Equal(t, 0, *cfgs[0].Document)
+	assert.Equal(t, "compute-us", cfgs[1].Metadata.Name)
+	assert.Equal(t, 1, *cfgs[1].Document)
+	assert.Equal(t, map[string]any{"workspace": "us"}, cfgs[1].Context)
+}
+
 // If a single valid file exists the readFile function should return a single api.TerraformConfiguration.
 func TestSearch_Success(t *testing.T) {
 	originalCwd, err := os.Getwd()
@@ -176,6 +210,23 @@ func TestSearch_MultipleFiles(t *testing.T) {
 	assert.Equal(t, expected, result)
 }
 
+// If any file is invalid the Search function should return the errors of every invalid file.
+func TestSearch_InvalidFiles(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	root := path.Join("..", "testdata", "terraform", "invalid-dir")
+	os.Chdir(root)
+
+	result, err := Search()
+
+	assert.ErrorContains(t, err, path.Join("b", "pantalon.yaml")+":2:13: invalid version")
+	assert.ErrorContains(t, err, path.Join("c", "pantalon.yaml")+":5:9: invalid metadata.name")
+	assert.Nil(t, result)
+}
+
 // If no files are found the Search function should return an empty slice.
 func TestSearch_NoFilesFound(t *testing.T) {
 	originalCwd, err := os.Getwd()
@@ -193,3 +244,25 @@ func TestSearch_NoFilesFound(t *testing.T) {
 
 	assert.Empty(t, result)
 }
+
+// Validate must report the errors of every invalid file, not only the first.
+func TestValidate_ReportsAllInvalidFiles(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	root := path.Join("..", "testdata", "terraform", "invalid-dir")
+	os.Chdir(root)
+
+	result, err := Validate()
+
+	assert.EqualError(t, err, path.Join("b", "pantalon.yaml")+":2:13: invalid version\n"+path.Join("c", "pantalon.yaml")+":5:9: invalid metadata.name")
+
+	var validationErr *api.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, path.Join("b", "pantalon.yaml"), validationErr.Path)
+	assert.Equal(t, "apiVersion", validationErr.Field)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "invalid-dir-a", result[0].Metadata.Name)
+}
@@ -0,0 +1,175 @@
+package file
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type hclTokenKind int
+
+const (
+	hclOther hclTokenKind = iota
+	hclIdent
+	hclString
+	hclPunct
+)
+
+// hclToken is a token of the HCL native syntax. Only the tokens needed to find block structure and
+// literal attribute values are distinguished, everything else is hclOther.
+type hclToken struct {
+	kind hclTokenKind
+	text string
+}
+
+var heredocRegexp = regexp.MustCompile(`^<<-?([A-Za-z_][A-Za-z0-9_-]*)\r?\n`)
+
+// templateEscapes replaces the escaped template sequences of a literal string with the sequence itself.
+var templateEscapes = strings.NewReplacer("$${", "${", "%%{", "%{")
+
+// scanHCL is a minimal lexer for the HCL native syntax used by Terraform .tf files, sufficient for finding the
+// source of module blocks without depending on the full HCL parser.
+//
+// Comments and heredocs are skipped. Quoted strings without template sequences are returned as hclString
+// with their unquoted value, strings containing ${ or %{ sequences are returned as hclOther.
+//
+// It doesn't support, or differs from HCL in:
+//   - heredocs, which are a single hclOther token even without template sequences, and must have the newline
+//     directly after their marker
+//   - identifiers, which are ASCII only, so other letters are scanned as hclOther
+//   - escape sequences, which are those of Go, a superset of those of HCL
+//   - numbers and operators, which are not distinguished from other tokens
+//   - errors, as an unterminated string ends at the end of its line and an unterminated comment, heredoc or
+//     template at the end of src, without reporting them
+func scanHCL(src []byte) []hclToken {
+	var tokens []hclToken
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '#' || (c == '/' && i+1 < len(src) && src[i+1] == '/'):
+			i = skipLine(src, i)
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return tokens
+			}
+			i += 2 + end + 2
+		case c == '"':
+			end, literal := scanString(src, i)
+			tok := hclToken{kind: hclOther}
+			if literal {
+				if value, err := strconv.Unquote(string(src[i:end])); err == nil {
+					tok = hclToken{kind: hclString, text: templateEscapes.Replace(value)}
+				}
+			}
+			tokens = append(tokens, tok)
+			i = end
+		case c == '<' && heredocRegexp.Match(src[i:]):
+			i = skipHeredoc(src, i)
+			tokens = append(tokens, hclToken{kind: hclOther})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, hclToken{kind: hclIdent, text: string(src[start:i])})
+		case c == '=' && i+1 < len(src) && (src[i+1] == '=' || src[i+1] == '>'):
+			tokens = append(tokens, hclToken{kind: hclOther})
+			i += 2
+		case c == '{' || c == '}' || c == '=':
+			tokens = append(tokens, hclToken{kind: hclPunct, text: string(c)})
+			i++
+		case (c == '!' || c == '<' || c == '>') && i+1 < len(src) && src[i+1] == '=':
+			tokens = append(tokens, hclToken{kind: hclOther})
+			i += 2
+		default:
+			tokens = append(tokens, hclToken{kind: hclOther})
+			i++
+		}
+	}
+	return tokens
+}
+
+// scanString returns the index after the closing quote of the string starting at src[start], and
+// whether the string is a literal without template sequences.
+func scanString(src []byte, start int) (int, bool) {
+	literal := true
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, literal
+		case '\n':
+			return i, false
+		case '$', '%':
+			if i+1 < len(src) && src[i+1] == '{' {
+				// $${ and %%{ are escaped literal sequences.
+				if i > start+1 && src[i-1] == src[i] {
+					continue
+				}
+				literal = false
+				i = skipTemplate(src, i+2) - 1
+			}
+		}
+	}
+	return len(src), false
+}
+
+// skipTemplate returns the index after the closing brace of a template sequence, where start is the
+// index after its opening brace. Template sequences may contain nested braces and strings.
+func skipTemplate(src []byte, start int) int {
+	depth := 1
+	for i := start; i < len(src); {
+		switch src[i] {
+		case '"':
+			i, _ = scanString(src, i)
+			continue
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(src)
+}
+
+// skipHeredoc returns the index after the closing marker of the heredoc starting at src[start].
+func skipHeredoc(src []byte, start int) int {
+	m := heredocRegexp.FindSubmatch(src[start:])
+	marker := m[1]
+
+	for i := start + len(m[0]); i < len(src); {
+		end := skipLine(src, i)
+		if bytes.Equal(bytes.TrimSpace(src[i:end]), marker) {
+			return end
+		}
+		i = end + 1
+	}
+	return len(src)
+}
+
+// skipLine returns the index of the next newline, or the end of src.
+func skipLine(src []byte, start int) int {
+	end := bytes.IndexByte(src[start:], '\n')
+	if end < 0 {
+		return len(src)
+	}
+	return start + end
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanHCL(t *testing.T) {
+	other := hclToken{kind: hclOther}
+	open := hclToken{kind: hclPunct, text: "{"}
+	closing := hclToken{kind: hclPunct, text: "}"}
+	equals := hclToken{kind: hclPunct, text: "="}
+	ident := func(text string) hclToken { return hclToken{kind: hclIdent, text: text} }
+	str := func(text string) hclToken { return hclToken{kind: hclString, text: text} }
+
+	tests := []struct {
+		name     string
+		src      string
+		expected []hclToken
+	}{
+		{
+			name:     "heredoc",
+			src:      "a = <<EOT\n{ \"b\" }\nEOT\nc = \"d\"\n",
+			expected: []hclToken{ident("a"), equals, other, ident("c"), equals, str("d")},
+		},
+		{
+			name:     "indented heredoc",
+			src:      "a = <<-EOT\n    }\n    EOT\n}",
+			expected: []hclToken{ident("a"), equals, other, closing},
+		},
+		{
+			name:     "heredoc marker within a line",
+			src:      "a = <<EOT\nnot EOT\nEOT-2\nEOT\nb",
+			expected: []hclToken{ident("a"), equals, other, ident("b")},
+		},
+		{
+			name:     "heredoc marker with a hyphen",
+			src:      "a = <<END-OF-TEXT\n{\nEND-OF-TEXT\nb",
+			expected: []hclToken{ident("a"), equals, other, ident("b")},
+		},
+		{
+			name:     "heredoc with templates",
+			src:      "a = <<EOT\n${ \"}\" }%{ if true }{%{ endif }\nEOT\n}",
+			expected: []hclToken{ident("a"), equals, other, closing},
+		},
+		{
+			name:     "unterminated heredoc",
+			src:      "a = <<EOT\n}\n",
+			expected: []hclToken{ident("a"), equals, other},
+		},
+		{
+			name:     "not a heredoc",
+			src:      "a << EOT",
+			expected: []hclToken{ident("a"), other, other, ident("EOT")},
+		},
+		{
+			name:     "template with nested braces",
+			src:      `a = "${ {b = 1}["b"] }" {`,
+			expected: []hclToken{ident("a"), equals, other, open},
+		},
+		{
+			name:     "template with a nested string",
+			src:      `a = "${ "}" }" }`,
+			expected: []hclToken{ident("a"), equals, other, closing},
+		},
+		{
+			name:     "template directive",
+			src:      `a = "%{ if b }c%{ endif }"`,
+			expected: []hclToken{ident("a"), equals, other},
+		},
+		{
+			name:     "escaped template sequences",
+			src:      `a = "$${b}-%%{c}"`,
+			expected: []hclToken{ident("a"), equals, str("${b}-%{c}")},
+		},
+		{
+			name:     "escapes",
+			src:      `a = "b\"c\u00e9\n"`,
+			expected: []hclToken{ident("a"), equals, str("b\"cé\n")},
+		},
+		{
+			name:     "string ended by a newline",
+			src:      "a = \"b\nc",
+			expected: []hclToken{ident("a"), equals, other, ident("c")},
+		},
+		{
+			name:     "unterminated template",
+			src:      `a = "${b`,
+			expected: []hclToken{ident("a"), equals, other},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, scanHCL([]byte(tt.src)))
+		})
+	}
+}
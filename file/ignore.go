@@ -0,0 +1,212 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreFiles are read in each directory searched, using the syntax of .gitignore. A .pantalonignore is read after
+// the .gitignore of the same directory, so its patterns take precedence.
+var ignoreFiles = []string{".gitignore", ".pantalonignore"}
+
+// ignoredDirs are never searched: the git database, and the module and provider cache of `terraform init`, which
+// contains copies of other repositories.
+var ignoredDirs = map[string]bool{
+	".git":       true,
+	".terraform": true,
+}
+
+// ignorePattern is a single line of an ignore file.
+type ignorePattern struct {
+	// glob is a doublestar pattern matched against a path relative to the directory of the ignore file.
+	glob    string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules are the patterns of the ignore files of a directory.
+type ignoreRules struct {
+	// prefix is prepended to paths relative to the working directory to make them relative to the directory of the
+	// ignore files, for ignore files in a parent of the working directory.
+	prefix   string
+	patterns []ignorePattern
+}
+
+// ignorer reports whether paths relative to the working directory are ignored by the ignore files of the git
+// repository, reading them as they're needed.
+type ignorer struct {
+	// outer are the rules of the parents of the working directory within the git repository, outermost first.
+	outer []ignoreRules
+	// rules are the rules of each directory within the working directory, keyed by slash separated path.
+	rules map[string][]ignorePattern
+}
+
+func newIgnorer() (*ignorer, error) {
+	ig := &ignorer{rules: make(map[string][]ignorePattern)}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	// Ignore files above the working directory only apply within a git repository.
+	var parents []string
+	for dir := cwd; !isGitRoot(dir); {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ig, nil
+		}
+		dir = parent
+		parents = append(parents, dir)
+	}
+
+	for i := len(parents) - 1; i >= 0; i-- {
+		patterns, err := readIgnoreFiles(parents[i])
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(parents[i], cwd)
+		if err != nil {
+			return nil, err
+		}
+		ig.outer = append(ig.outer, ignoreRules{prefix: filepath.ToSlash(rel) + "/", patterns: patterns})
+	}
+	return ig, nil
+}
+
+func isGitRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// ignored reports whether the file or directory p, relative to the working directory, is ignored. The parents of p
+// are assumed not to be ignored, as the walk doesn't enter ignored directories.
+func (ig *ignorer) ignored(p string, isDir bool) (bool, error) {
+	p = filepath.ToSlash(filepath.Clean(p))
+
+	ignored := false
+	for _, rules := range ig.outer {
+		ignored = matchIgnore(rules.patterns, rules.prefix+p, isDir, ignored)
+	}
+
+	// The rules of each parent directory apply in turn, so deeper ignore files take precedence.
+	var dirs []string
+	for dir := path.Dir(p); ; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+		if dir == "." {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		patterns, err := ig.load(dirs[i])
+		if err != nil {
+			return false, err
+		}
+		rel := p
+		if dirs[i] != "." {
+			rel = strings.TrimPrefix(p, dirs[i]+"/")
+		}
+		ignored = matchIgnore(patterns, rel, isDir, ignored)
+	}
+	return ignored, nil
+}
+
+// load returns the patterns of the ignore files of dir, reading them the first time.
+func (ig *ignorer) load(dir string) ([]ignorePattern, error) {
+	if patterns, ok := ig.rules[dir]; ok {
+		return patterns, nil
+	}
+	patterns, err := readIgnoreFiles(filepath.FromSlash(dir))
+	if err != nil {
+		return nil, err
+	}
+	ig.rules[dir] = patterns
+	return patterns, nil
+}
+
+func readIgnoreFiles(dir string) ([]ignorePattern, error) {
+	var patterns []ignorePattern
+	for _, name := range ignoreFiles {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		patterns = append(patterns, parseIgnore(string(b))...)
+	}
+	return patterns, nil
+}
+
+// matchIgnore returns whether p is ignored after applying patterns, where the last matching pattern wins. ignored is
+// the result of the patterns of the parent directories.
+func matchIgnore(patterns []ignorePattern, p string, isDir bool, ignored bool) bool {
+	for _, pattern := range patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		// Patterns are validated when parsed.
+		if matched, _ := doublestar.Match(pattern.glob, p); matched {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// parseIgnore parses the lines of an ignore file with the syntax of .gitignore.
+func parseIgnore(content string) []ignorePattern {
+	var patterns []ignorePattern
+	for _, line := range strings.Split(content, "\n") {
+		line = trimIgnoreLine(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern ignorePattern
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// A pattern with a slash, other than at the end, is relative to the directory of the ignore file. Otherwise
+		// it matches a name at any depth.
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+
+		// Braces are literal in .gitignore, but alternatives in doublestar.
+		line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+		if !doublestar.ValidatePattern(line) {
+			continue
+		}
+		pattern.glob = line
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// trimIgnoreLine removes trailing spaces from line, unless they're escaped with a backslash.
+func trimIgnoreLine(line string) string {
+	trimmed := strings.TrimRight(line, " ")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		return trimmed[:len(trimmed)-1] + " "
+	}
+	return trimmed
+}
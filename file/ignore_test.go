@@ -0,0 +1,118 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchIgnore(t *testing.T) {
+	patterns := parseIgnore(`# comment
+node_modules/
+/build
+*.log
+docs/**/generated
+!keep.log
+\#literal
+trailing\ 
+`)
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"node_modules", true, true},
+		{"a/b/node_modules", true, true},
+		{"node_modules", false, false},
+		{"build", true, true},
+		{"a/build", true, false},
+		{"debug.log", false, true},
+		{"a/debug.log", false, true},
+		{"keep.log", false, false},
+		{"docs/generated", true, true},
+		{"docs/a/b/generated", true, true},
+		{"a/docs/generated", true, false},
+		{"#literal", false, true},
+		{"trailing ", false, true},
+		{"comment", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchIgnore(patterns, tt.path, tt.isDir, false))
+		})
+	}
+}
+
+func TestMatchIgnore_NegationOverridesParent(t *testing.T) {
+	parent := parseIgnore("vendor/\n")
+	child := parseIgnore("!vendor/\n")
+
+	ignored := matchIgnore(parent, "a/vendor", true, false)
+	assert.True(t, ignored)
+	assert.False(t, matchIgnore(child, "vendor", true, ignored))
+}
+
+// writeFiles creates each file, with its parent directories, within dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestWalkDir_IgnoredDirectoriesAreNotSearched(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":        "node_modules/\n",
+		"app/pantalon.yaml": "",
+		"app/.terraform/modules/vpc/pantalon.yaml": "",
+		"node_modules/pkg/pantalon.yaml":           "",
+		"vendor/.pantalonignore":                   "legacy/\n",
+		"vendor/legacy/pantalon.yaml":              "",
+		"vendor/current/pantalon.yaml":             "",
+		"archived/pantalon.yaml":                   "",
+		"restored/.gitignore":                      "*\n",
+		"restored/.pantalonignore":                 "!*\n",
+		"restored/stack/pantalon.yaml":             "",
+		".git/pantalon.yaml":                       "",
+	})
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(dir)
+
+	paths, err := Options{Exclude: []string{"archived"}}.Paths()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/pantalon.yaml", "restored/stack/pantalon.yaml", "vendor/current/pantalon.yaml"}, paths)
+}
+
+func TestWalkDir_IgnoreFilesAboveWorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".git/HEAD":                     "",
+		".gitignore":                    "/infra/generated/\n",
+		"infra/generated/pantalon.yaml": "",
+		"infra/app/pantalon.yaml":       "",
+		"generated/pantalon.yaml":       "",
+	})
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join(dir, "infra"))
+
+	paths, err := findFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/pantalon.yaml"}, paths)
+}
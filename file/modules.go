@@ -0,0 +1,150 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// LocalModules sets the Modules of each item to the local module directories it transitively consumes.
+//
+// The .tf files in each configuration directory are read for module blocks with a local source, such as
+// `source = "../modules/foo"`. The .tf files of each module are then read in turn. Registry and remote
+// module sources are ignored.
+func LocalModules(items []api.ConfigurationItem) ([]api.ConfigurationItem, error) {
+	graph := moduleGraph{sources: make(map[string][]string)}
+
+	result := make([]api.ConfigurationItem, 0, len(items))
+	for _, item := range items {
+		modules, err := graph.transitive(item.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(modules) > 0 {
+			item.Modules = modules
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// moduleGraph caches the local module sources read from each directory.
+type moduleGraph struct {
+	sources map[string][]string
+}
+
+// transitive returns every local module directory consumed by dir, directly or indirectly, sorted.
+func (g moduleGraph) transitive(dir string) ([]string, error) {
+	visited := map[string]bool{dir: true}
+	queue := []string{dir}
+	var modules []string
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		sources, err := g.direct(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			if visited[source] {
+				continue
+			}
+			visited[source] = true
+			modules = append(modules, source)
+			queue = append(queue, source)
+		}
+	}
+
+	sort.Strings(modules)
+	return modules, nil
+}
+
+// direct returns the local module directories referenced by the .tf files in dir.
+func (g moduleGraph) direct(dir string) ([]string, error) {
+	if sources, ok := g.sources[dir]; ok {
+		return sources, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		// A module which no longer exists has no sources, but is still consumed.
+		if errors.Is(err, os.ErrNotExist) {
+			g.sources[dir] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sources []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".tf" {
+			continue
+		}
+
+		src, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, source := range moduleSources(src) {
+			if isLocalModuleSource(source) {
+				sources = append(sources, filepath.Join(dir, filepath.FromSlash(source)))
+			}
+		}
+	}
+
+	g.sources[dir] = sources
+	return sources, nil
+}
+
+// Terraform only treats sources beginning with ./ or ../ as local paths.
+//
+// As described in https://developer.hashicorp.com/terraform/language/modules/sources#local-paths
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// moduleSources returns the source attribute of every top level module block in a Terraform file.
+func moduleSources(src []byte) []string {
+	tokens := scanHCL(src)
+
+	var sources []string
+	depth := 0
+	inModule := false
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.kind == hclPunct && tok.text == "{":
+			depth++
+		case tok.kind == hclPunct && tok.text == "}":
+			depth--
+			if depth <= 0 {
+				depth = 0
+				inModule = false
+			}
+		case depth == 0 && tok.kind == hclIdent && tok.text == "module":
+			// module "name" {
+			if i+2 < len(tokens) && (tokens[i+1].kind == hclString || tokens[i+1].kind == hclIdent) &&
+				tokens[i+2].kind == hclPunct && tokens[i+2].text == "{" {
+				inModule = true
+				depth++
+				i += 2
+			}
+		case depth == 1 && inModule && tok.kind == hclIdent && tok.text == "source":
+			// source = "../modules/foo"
+			if i+2 < len(tokens) && tokens[i+1].kind == hclPunct && tokens[i+1].text == "=" &&
+				tokens[i+2].kind == hclString {
+				sources = append(sources, tokens[i+2].text)
+				i += 2
+			}
+		}
+	}
+	return sources
+}
@@ -0,0 +1,92 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleSources(t *testing.T) {
+	src := []byte(`
+# module "commented" { source = "./commented" }
+// module "commented" { source = "./commented" }
+/*
+module "commented" {
+  source = "./commented"
+}
+*/
+module "local" {
+  source = "./local"
+
+  description = <<-EOT
+    module "heredoc" {
+      source = "./heredoc"
+    }
+  EOT
+  name = "${var.prefix}-{name}"
+  tags = { source = "./not-a-source" }
+}
+
+module registry {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+
+resource "null_resource" "source" {
+  source = "./resource"
+}
+
+module "interpolated" {
+  source = "./${var.name}"
+}
+`)
+
+	assert.Equal(t, []string{"./local", "terraform-aws-modules/vpc/aws"}, moduleSources(src))
+}
+
+func TestIsLocalModuleSource(t *testing.T) {
+	assert.True(t, isLocalModuleSource("./modules/foo"))
+	assert.True(t, isLocalModuleSource("../modules/foo"))
+	assert.False(t, isLocalModuleSource("terraform-aws-modules/vpc/aws"))
+	assert.False(t, isLocalModuleSource("git::https://example.com/vpc.git"))
+	assert.False(t, isLocalModuleSource("modules/foo"))
+}
+
+func TestLocalModules_Transitive(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "testdata", "terraform", "modules-dir"))
+
+	items := []api.ConfigurationItem{
+		{Name: "modules-dir-dev", Dir: filepath.Join("environments", "dev")},
+		{Name: "modules-dir-prod", Dir: filepath.Join("environments", "prod")},
+	}
+
+	result, err := LocalModules(items)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join("modules", "app"),
+		filepath.Join("modules", "network"),
+	}, result[0].Modules)
+	assert.Equal(t, []string{
+		filepath.Join("modules", "network"),
+	}, result[1].Modules)
+}
+
+func TestLocalModules_NoModules(t *testing.T) {
+	items := []api.ConfigurationItem{
+		{Name: "single-dir", Dir: filepath.Join("..", "testdata", "terraform", "single-dir")},
+	}
+
+	result, err := LocalModules(items)
+	require.NoError(t, err)
+	assert.Equal(t, items, result)
+}
@@ -0,0 +1,28 @@
+package file
+
+import (
+	"github.com/kallangerard/pantalon/api"
+)
+
+// Owner returns the configurations owning a file or directory, which are those with the deepest directory containing
+// path. A directory is owned by more than one configuration when its pantalon.yaml has several documents or a matrix.
+func Owner(items []api.ConfigurationItem, path string) ([]api.ConfigurationItem, bool) {
+	trie := newDirTrie()
+	for i, item := range items {
+		trie.insert(item.Dir, i)
+	}
+
+	var owners []int
+	trie.walk(path, func(node *dirTrie, rest []string) {
+		owners = node.items
+	})
+
+	if len(owners) == 0 {
+		return nil, false
+	}
+	result := make([]api.ConfigurationItem, 0, len(owners))
+	for _, i := range owners {
+		result = append(result, items[i])
+	}
+	return result, true
+}
@@ -0,0 +1,46 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var ownerItems = []api.ConfigurationItem{
+	{Name: "dev", Dir: "terraform/compute/environments/dev"},
+	{Name: "dev-2", Dir: "terraform/compute/environments/dev-2"},
+	{Name: "nested", Dir: "terraform/compute/environments/dev/nested"},
+	{Name: "prod-eu", Dir: "terraform/compute/environments/prod"},
+	{Name: "prod-us", Dir: "terraform/compute/environments/prod"},
+}
+
+func TestOwner(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{path: "terraform/compute/environments/dev", expected: []string{"dev"}},
+		{path: "terraform/compute/environments/dev/main.tf", expected: []string{"dev"}},
+		{path: "terraform/compute/environments/dev-2/modules/foo", expected: []string{"dev-2"}},
+		{path: "terraform/compute/environments/dev/nested/main.tf", expected: []string{"nested"}},
+		{path: "terraform/compute/environments/prod/main.tf", expected: []string{"prod-eu", "prod-us"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			owners, ok := Owner(ownerItems, tt.path)
+			assert.True(t, ok)
+			var names []string
+			for _, owner := range owners {
+				names = append(names, owner.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestOwner_NotOwned(t *testing.T) {
+	_, ok := Owner(ownerItems, "terraform/compute/README.md")
+	assert.False(t, ok)
+}
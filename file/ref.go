@@ -0,0 +1,145 @@
+package file
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/kallangerard/pantalon/git"
+)
+
+// SearchRef finds and reads the pantalon.yaml files as they exist at a git ref, without checking it out.
+//
+// As with Search, a pantalon.yaml nested within the directory of another is not included.
+func SearchRef(ref string) ([]api.TerraformConfiguration, error) {
+	return Options{}.SearchRef(ref)
+}
+
+// SearchRef finds and reads the pantalon.yaml files as they exist at a git ref, within o.Roots and not excluded by
+// o.Exclude. A pantalon.yaml nested within the directory of another is only included if o.Nested is set.
+func (o Options) SearchRef(ref string) ([]api.TerraformConfiguration, error) {
+	listed, err := git.ListFiles(ref, "pantalon.yaml")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range listed {
+		included, err := o.included(path)
+		if err != nil {
+			return nil, err
+		}
+		if included {
+			paths = append(paths, path)
+		}
+	}
+	if o.Nested {
+		sort.Strings(paths)
+	} else {
+		paths = outermostFiles(paths)
+	}
+
+	defaultsPaths, err := git.ListFiles(ref, DefaultsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := git.ReadFiles(ref, append(slices.Clone(paths), defaultsPaths...))
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, errs := readContextDefaults(paths, o.Defaults, func(path string) ([]byte, bool, error) {
+		b, ok := files[path]
+		return b, ok, nil
+	}, ref)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	result := make([]api.TerraformConfiguration, 0, len(paths))
+	for _, path := range paths {
+		cfg := api.New()
+		tfCfgs, err := cfg.UnmarshalAll(files[path])
+		if err != nil {
+			return nil, api.WithPath(err, path+"@"+ref)
+		}
+		for _, tfCfg := range tfCfgs {
+			tfCfg.Path = path
+			tfCfg, _ = defaults.apply(tfCfg)
+			result = append(result, tfCfg)
+		}
+	}
+	return result, nil
+}
+
+// Removed returns the configurations which existed at the merge-base of baseRef and headRef, but whose pantalon.yaml
+// no longer exists at headRef.
+func Removed(baseRef, headRef string) ([]api.TerraformConfiguration, error) {
+	return Options{}.Removed(baseRef, headRef)
+}
+
+// Removed returns the configurations which existed at the merge-base of baseRef and headRef, within o.Roots and not
+// excluded by o.Exclude, but whose pantalon.yaml no longer exists at headRef.
+//
+// Reading the merge-base rather than baseRef matches the changes introduced by a pull request, so configurations
+// added to baseRef since headRef branched from it aren't reported as removed. Reading headRef rather than the working
+// tree matches --base-ref, so uncommitted changes aren't included.
+func (o Options) Removed(baseRef, headRef string) ([]api.TerraformConfiguration, error) {
+	mergeBase, err := git.MergeBase(baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := o.SearchRef(mergeBase)
+	if err != nil {
+		return nil, err
+	}
+	head, err := o.SearchRef(headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[string]bool, len(head))
+	for _, cfg := range head {
+		exists[filepath.Clean(cfg.Path)] = true
+	}
+
+	removed := make([]api.TerraformConfiguration, 0)
+	for _, cfg := range base {
+		if !exists[filepath.Clean(cfg.Path)] {
+			removed = append(removed, cfg)
+		}
+	}
+	return removed, nil
+}
+
+// outermostFiles sorts paths and removes any path within the directory of another.
+func outermostFiles(paths []string) []string {
+	dirs := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	result := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if !hasAncestor(filepath.Dir(path), dirs) {
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// hasAncestor reports whether any parent directory of dir is in dirs.
+func hasAncestor(dir string, dirs map[string]bool) bool {
+	for dir != "." {
+		dir = filepath.Dir(dir)
+		if dirs[dir] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package file
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates a git repository in a temporary directory, commits files to it, and changes into it.
+func newTestRepo(t *testing.T, files map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(t.TempDir())
+
+	for path, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "-A"},
+		{"-c", "user.email=test@example.com", "-c", "user.name=test", "-c", "commit.gpgsign=false", "commit", "--quiet", "-m", "initial"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+}
+
+func gitCmd(t *testing.T, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.email=test@example.com", "-c", "user.name=test", "-c", "commit.gpgsign=false"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func pantalonYaml(name string) string {
+	return `---
+apiVersion: pantalon.kallan.dev/v1alpha1
+kind: TerraformConfiguration
+metadata:
+  name: ` + name + `
+context:
+  env: ` + name + `
+`
+}
+
+func TestSearchRef(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"a/pantalon.yaml":       pantalonYaml("a"),
+		"a/child/pantalon.yaml": pantalonYaml("child"),
+		"b/pantalon.yaml":       pantalonYaml("b"),
+	})
+
+	result, err := SearchRef("HEAD")
+	require.NoError(t, err)
+
+	assert.Equal(t, []api.TerraformConfiguration{
+		{
+			ApiVersion: api.PantalonVersion,
+			Kind:       api.TerraformKind,
+			Metadata:   api.Metadata{Name: "a"},
+			Context:    map[string]any{"env": "a"},
+			Path:       filepath.Join("a", "pantalon.yaml"),
+		},
+		{
+			ApiVersion: api.PantalonVersion,
+			Kind:       api.TerraformKind,
+			Metadata:   api.Metadata{Name: "b"},
+			Context:    map[string]any{"env": "b"},
+			Path:       filepath.Join("b", "pantalon.yaml"),
+		},
+	}, result)
+}
+
+func TestSearchRef_InvalidFile(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"a/pantalon.yaml": strings.Replace(pantalonYaml("a"), "v1alpha1", "v1", 1),
+	})
+
+	_, err := SearchRef("HEAD")
+	assert.EqualError(t, err, filepath.Join("a", "pantalon.yaml")+"@HEAD:2:13: invalid version")
+}
+
+func TestRemoved(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"a/pantalon.yaml": pantalonYaml("a"),
+		"b/pantalon.yaml": pantalonYaml("b"),
+	})
+	gitCmd(t, "branch", "base")
+	require.NoError(t, os.RemoveAll("b"))
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "--quiet", "-m", "remove b")
+
+	removed, err := Removed("base", "HEAD")
+	require.NoError(t, err)
+
+	require.Len(t, removed, 1)
+	assert.Equal(t, "b", removed[0].Metadata.Name)
+	assert.Equal(t, filepath.Join("b", "pantalon.yaml"), removed[0].Path)
+	assert.Equal(t, map[string]any{"env": "b"}, removed[0].Context)
+}
+
+func TestRemoved_ReadsHeadRef(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"a/pantalon.yaml": pantalonYaml("a"),
+		"b/pantalon.yaml": pantalonYaml("b"),
+	})
+	gitCmd(t, "branch", "base")
+	gitCmd(t, "checkout", "--quiet", "-b", "feature")
+	require.NoError(t, os.RemoveAll("a"))
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "--quiet", "-m", "remove a")
+	gitCmd(t, "checkout", "--quiet", "base")
+
+	// b is only removed from the working tree, which isn't headRef.
+	require.NoError(t, os.RemoveAll("b"))
+
+	removed, err := Removed("base", "feature")
+	require.NoError(t, err)
+
+	require.Len(t, removed, 1)
+	assert.Equal(t, "a", removed[0].Metadata.Name)
+}
+
+func TestRemoved_BaseMovedAhead(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"a/pantalon.yaml": pantalonYaml("a"),
+		"b/pantalon.yaml": pantalonYaml("b"),
+	})
+	gitCmd(t, "branch", "base")
+
+	gitCmd(t, "checkout", "--quiet", "-b", "feature")
+	require.NoError(t, os.RemoveAll("b"))
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "--quiet", "-m", "remove b")
+
+	// The base branch gains a configuration the feature branch has never seen.
+	gitCmd(t, "checkout", "--quiet", "base")
+	require.NoError(t, os.MkdirAll("c", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join("c", "pantalon.yaml"), []byte(pantalonYaml("c")), 0o644))
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "--quiet", "-m", "add c")
+	gitCmd(t, "checkout", "--quiet", "feature")
+
+	removed, err := Removed("base", "HEAD")
+	require.NoError(t, err)
+
+	require.Len(t, removed, 1)
+	assert.Equal(t, "b", removed[0].Metadata.Name)
+}
+
+func TestOutermostFiles(t *testing.T) {
+	paths := []string{
+		filepath.Join("a", "b", "pantalon.yaml"),
+		filepath.Join("a", "pantalon.yaml"),
+		filepath.Join("a-b", "pantalon.yaml"),
+		filepath.Join("c", "d", "e", "pantalon.yaml"),
+	}
+
+	assert.ElementsMatch(t, []string{
+		filepath.Join("a", "pantalon.yaml"),
+		filepath.Join("a-b", "pantalon.yaml"),
+		filepath.Join("c", "d", "e", "pantalon.yaml"),
+	}, outermostFiles(paths))
+}
+
+func TestSearchRef_ContextDefaults(t *testing.T) {
+	newTestRepo(t, map[string]string{
+		"pantalon.defaults.yaml":   contextDefaultsYaml("region: eu\n    env: default"),
+		"a/pantalon.yaml":          pantalonYaml("a"),
+		"b/pantalon.defaults.yaml": contextDefaultsYaml("region: us"),
+		"b/pantalon.yaml":          pantalonYaml("b"),
+	})
+
+	result, err := SearchRef("HEAD")
+	require.NoError(t, err)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, map[string]any{"env": "a", "region": "eu"}, result[0].Context)
+	assert.Equal(t, map[string]string{"region": "pantalon.defaults.yaml"}, result[0].ContextSources)
+	assert.Equal(t, map[string]any{"env": "b", "region": "us"}, result[1].Context)
+	assert.Equal(t, map[string]string{"region": filepath.Join("b", "pantalon.defaults.yaml")}, result[1].ContextSources)
+}
@@ -0,0 +1,73 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/kallangerard/pantalon/api"
+)
+
+// RepositoryFile is the name of the repository configuration file.
+const RepositoryFile = ".pantalon.yaml"
+
+// FindRepository returns the .pantalon.yaml in dir or its closest parent directory, stopping at the root of a git
+// repository. It returns false if there is none.
+func FindRepository(dir string) (api.RepositoryConfiguration, bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return api.RepositoryConfiguration{}, false, err
+	}
+
+	for {
+		path := filepath.Join(dir, RepositoryFile)
+		cfg, err := ReadRepository(path)
+		if err == nil {
+			return cfg, true, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return api.RepositoryConfiguration{}, false, err
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return api.RepositoryConfiguration{}, false, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return api.RepositoryConfiguration{}, false, nil
+		}
+		dir = parent
+	}
+}
+
+// ReadRepository reads the .pantalon.yaml at path, setting its Dir and Path.
+func ReadRepository(path string) (api.RepositoryConfiguration, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return api.RepositoryConfiguration{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg, err := api.UnmarshalRepository(b)
+	if err != nil {
+		return api.RepositoryConfiguration{}, api.WithPath(err, path)
+	}
+
+	names := make([]string, 0, len(cfg.Spec.Presets))
+	for name := range cfg.Spec.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if match := cfg.Spec.Presets[name].ChangedDirsMatch; match != "" {
+			if _, err := ParseDirMatch(match); err != nil {
+				return api.RepositoryConfiguration{}, fmt.Errorf("%s: invalid spec.presets.%s.changedDirsMatch: %w", path, name, err)
+			}
+		}
+	}
+
+	cfg.Dir = filepath.Dir(path)
+	cfg.Path = path
+	return cfg, nil
+}
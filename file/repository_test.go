@@ -0,0 +1,92 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRepository(t *testing.T) {
+	root, err := filepath.Abs(filepath.Join("..", "testdata", "terraform", "repository-dir"))
+	require.NoError(t, err)
+
+	cfg, ok, err := FindRepository(filepath.Join(root, "terraform", "app"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	assert.Equal(t, root, cfg.Dir)
+	assert.Equal(t, []string{"terraform"}, cfg.Spec.Roots)
+	assert.Equal(t, "yaml", cfg.Spec.OutputFormat)
+}
+
+func TestFindRepository_StopsAtGitRoot(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "terraform"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, RepositoryFile), []byte("---\n"), 0o644))
+
+	_, ok, err := FindRepository(filepath.Join(repo, "terraform"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestReadRepository_InvalidPreset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RepositoryFile)
+	require.NoError(t, os.WriteFile(path, []byte(`---
+apiVersion: pantalon.kallan.dev/v1beta1
+kind: RepositoryConfiguration
+spec:
+  presets:
+    prod:
+      changedDirsMatch: siblings
+`), 0o644))
+
+	_, err := ReadRepository(path)
+	assert.EqualError(t, err, path+`: invalid spec.presets.prod.changedDirsMatch: invalid directory match "siblings": must be exact, descendants or ancestors`)
+}
+
+func TestOptions_RootsAndExclude(t *testing.T) {
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(filepath.Join("..", "testdata", "terraform", "repository-dir"))
+
+	paths, err := Options{}.Paths()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other/pantalon.yaml", "terraform/app/pantalon.yaml", "terraform/archived/old/pantalon.yaml"}, paths)
+
+	opts := Options{Roots: []string{"terraform", "terraform/app"}, Exclude: []string{"**/archived"}}
+	paths, err = opts.Paths()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terraform/app/pantalon.yaml"}, paths)
+}
+
+func TestOptions_Included(t *testing.T) {
+	opts := Options{Roots: []string{"terraform"}, Exclude: []string{"**/archived"}}
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"terraform/app/pantalon.yaml", true},
+		{"terraform/pantalon.yaml", true},
+		{"terraform/archived/old/pantalon.yaml", false},
+		{"terraform/archived/pantalon.yaml", false},
+		{"terraform-old/pantalon.yaml", false},
+		{"other/pantalon.yaml", false},
+		{"pantalon.yaml", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			included, err := opts.included(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, included)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package file
+
+import (
+	"github.com/kallangerard/pantalon/api"
+)
+
+// SelectorFilter returns items whose labels match any of the provided label selectors.
+func SelectorFilter(items []api.ConfigurationItem, selectors []string) ([]api.ConfigurationItem, error) {
+	if len(selectors) == 0 {
+		return items, nil
+	}
+
+	parsed := make([]api.Selector, 0, len(selectors))
+	for _, s := range selectors {
+		selector, err := api.ParseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, selector)
+	}
+
+	filtered := make([]api.ConfigurationItem, 0)
+	for _, item := range items {
+		for _, selector := range parsed {
+			if selector.Matches(item.Labels) {
+				filtered = append(filtered, item)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,60 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/kallangerard/pantalon/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var selectorItems = []api.ConfigurationItem{
+	{
+		Name:   "compute-dev",
+		Dir:    "terraform/compute/environments/dev",
+		Labels: map[string]string{"tier": "dev", "team": "platform"},
+	},
+	{
+		Name:   "compute-prod",
+		Dir:    "terraform/compute/environments/prod",
+		Labels: map[string]string{"tier": "prod", "team": "platform"},
+	},
+	{
+		Name:   "data-prod",
+		Dir:    "terraform/data/environments/prod",
+		Labels: map[string]string{"tier": "prod", "team": "data"},
+	},
+	{
+		Name: "unlabelled",
+		Dir:  "terraform/unlabelled",
+	},
+}
+
+func TestSelectorFilter_Equality(t *testing.T) {
+	result, err := SelectorFilter(selectorItems, []string{"tier=prod"})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{selectorItems[1], selectorItems[2]}, result)
+}
+
+func TestSelectorFilter_SetAndEquality(t *testing.T) {
+	result, err := SelectorFilter(selectorItems, []string{"tier=prod,team in (data)"})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{selectorItems[2]}, result)
+}
+
+func TestSelectorFilter_MultipleSelectors_OR(t *testing.T) {
+	result, err := SelectorFilter(selectorItems, []string{"tier=dev", "!tier"})
+	require.NoError(t, err)
+	assert.Equal(t, []api.ConfigurationItem{selectorItems[0], selectorItems[3]}, result)
+}
+
+func TestSelectorFilter_NoSelectors_ReturnsAll(t *testing.T) {
+	result, err := SelectorFilter(selectorItems, nil)
+	require.NoError(t, err)
+	assert.Equal(t, selectorItems, result)
+}
+
+func TestSelectorFilter_InvalidSelector(t *testing.T) {
+	_, err := SelectorFilter(selectorItems, []string{"team in (data"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package file
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// dirTrie indexes directories by path segment, so lookups only match whole path components.
+type dirTrie struct {
+	children map[string]*dirTrie
+	// items holds the indices of the items registered at this directory.
+	items []int
+	// collected is set once the subtree has been collected, to avoid walking it again.
+	collected bool
+}
+
+func newDirTrie() *dirTrie {
+	return &dirTrie{children: make(map[string]*dirTrie)}
+}
+
+// splitDir splits a directory into path segments. The current directory "." has no segments.
+func splitDir(dir string) []string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	if dir == "." {
+		return nil
+	}
+	return strings.Split(dir, "/")
+}
+
+// insert registers item at dir.
+func (t *dirTrie) insert(dir string, item int) {
+	node := t
+	for _, segment := range splitDir(dir) {
+		child, ok := node.children[segment]
+		if !ok {
+			child = newDirTrie()
+			node.children[segment] = child
+		}
+		node = child
+	}
+	node.items = append(node.items, item)
+}
+
+// walk calls fn for every node on the path to dir which holds items, from the root down, with the
+// remaining segments of dir below that node. It returns the node for dir, or nil if dir is not in the trie.
+func (t *dirTrie) walk(dir string, fn func(node *dirTrie, rest []string)) *dirTrie {
+	segments := splitDir(dir)
+	node := t
+	for i := 0; ; i++ {
+		if len(node.items) > 0 {
+			fn(node, segments[i:])
+		}
+		if i == len(segments) {
+			return node
+		}
+		child, ok := node.children[segments[i]]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+}
+
+// collect calls fn for the items of every node in the subtree, including the node itself. Subtrees which
+// have already been collected are skipped.
+func (t *dirTrie) collect(fn func(item int)) {
+	if t.collected {
+		return
+	}
+	t.collected = true
+
+	for _, item := range t.items {
+		fn(item)
+	}
+	for _, child := range t.children {
+		child.collect(fn)
+	}
+}
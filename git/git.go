@@ -0,0 +1,174 @@
+// Package git reads changes from the local git repository using the git command line.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnexpectedDiffOutput    = errors.New("unexpected git diff output")
+	ErrUnexpectedCatFileOutput = errors.New("unexpected git cat-file output")
+	ErrFileNotFound            = errors.New("file not found")
+)
+
+// ChangedPaths returns the paths changed between the merge-base of baseRef and headRef, and headRef.
+//
+// This matches the changes introduced by a pull request from headRef into baseRef. Added, modified and
+// deleted paths are included, and both the old and new path of a renamed file are included. Paths are
+// relative to the current working directory, and changes outside it are excluded.
+func ChangedPaths(baseRef, headRef string) ([]string, error) {
+	out, err := run("diff", "--name-status", "-z", "--find-renames", "--relative", baseRef+"..."+headRef, "--")
+	if err != nil {
+		return nil, err
+	}
+	return parseNameStatus(out)
+}
+
+// ChangedDirs returns the unique directories containing the paths changed between baseRef and headRef.
+//
+// See ChangedPaths.
+func ChangedDirs(baseRef, headRef string) ([]string, error) {
+	paths, err := ChangedPaths(baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		dir := filepath.Dir(path)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// parseNameStatus parses the NUL separated output of `git diff --name-status -z`.
+//
+// Each entry is a status followed by a path, or two paths for renames and copies.
+func parseNameStatus(out []byte) ([]string, error) {
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return []string{}, nil
+	}
+
+	paths := make([]string, 0, len(fields)/2)
+	seen := make(map[string]bool, len(fields)/2)
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		count := 1
+		if strings.HasPrefix(status, "R") || strings.HasPrefix(status, "C") {
+			count = 2
+		}
+		if status == "" || i+count >= len(fields) {
+			return nil, fmt.Errorf("%w: %q", ErrUnexpectedDiffOutput, out)
+		}
+
+		for _, path := range fields[i+1 : i+1+count] {
+			path = filepath.FromSlash(path)
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+		i += 1 + count
+	}
+	return paths, nil
+}
+
+// MergeBase returns the commit of the best common ancestor of baseRef and headRef, which is the commit a pull
+// request from headRef into baseRef is compared against.
+func MergeBase(baseRef, headRef string) (string, error) {
+	out, err := run("merge-base", baseRef, headRef)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func run(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// ListFiles returns the paths of files at ref named name, relative to the current working directory.
+// Only files within the current working directory are included.
+func ListFiles(ref string, name string) ([]string, error) {
+	out, err := run("ls-tree", "-r", "-z", "--name-only", ref, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, path := range strings.Split(string(out), "\x00") {
+		if path != "" && filepath.Base(path) == name {
+			paths = append(paths, filepath.FromSlash(path))
+		}
+	}
+	return paths, nil
+}
+
+// ReadFiles returns the content of each path at ref, keyed by path, without checking out ref.
+// Paths are relative to the current working directory.
+func ReadFiles(ref string, paths []string) (map[string][]byte, error) {
+	var stdin bytes.Buffer
+	for _, path := range paths {
+		fmt.Fprintf(&stdin, "%s:./%s\n", ref, filepath.ToSlash(path))
+	}
+
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Stdin = &stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file --batch: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseCatFileBatch(out, paths)
+}
+
+// parseCatFileBatch parses the output of `git cat-file --batch`, which is a header line of
+// `<object> <type> <size>` followed by the content for each requested object.
+func parseCatFileBatch(out []byte, paths []string) (map[string][]byte, error) {
+	files := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		header, rest, ok := bytes.Cut(out, []byte("\n"))
+		if !ok {
+			return nil, fmt.Errorf("%w: missing header for %s", ErrUnexpectedCatFileOutput, path)
+		}
+
+		fields := strings.Fields(string(header))
+		if len(fields) == 2 && fields[1] == "missing" {
+			return nil, fmt.Errorf("%s: %w", path, ErrFileNotFound)
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("%w: %q", ErrUnexpectedCatFileOutput, header)
+		}
+
+		size, err := strconv.Atoi(fields[2])
+		if err != nil || size+1 > len(rest) {
+			return nil, fmt.Errorf("%w: %q", ErrUnexpectedCatFileOutput, header)
+		}
+
+		files[path] = rest[:size]
+		out = rest[size+1:]
+	}
+	return files, nil
+}
@@ -0,0 +1,196 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates a git repository in a temporary directory and changes into it.
+func newTestRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	originalCwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(originalCwd) })
+	os.Chdir(t.TempDir())
+
+	gitCmd(t, "init", "--quiet", "--initial-branch=main")
+	gitCmd(t, "config", "user.email", "test@example.com")
+	gitCmd(t, "config", "user.name", "test")
+	gitCmd(t, "config", "commit.gpgsign", "false")
+}
+
+func gitCmd(t *testing.T, args ...string) {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func commitAll(t *testing.T, message string) {
+	t.Helper()
+	gitCmd(t, "add", "-A")
+	gitCmd(t, "commit", "--quiet", "-m", message)
+}
+
+func TestChangedPaths_MergeBaseDiff(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/main.tf", "# a\n")
+	writeFile(t, "b/main.tf", "# b\n")
+	writeFile(t, "c/main.tf", "resource \"null_resource\" \"c\" {}\n")
+	commitAll(t, "initial")
+
+	gitCmd(t, "checkout", "--quiet", "-b", "feature")
+	writeFile(t, "a/main.tf", "# a changed\n")
+	require.NoError(t, os.Remove("b/main.tf"))
+	gitCmd(t, "mv", "c", "d")
+	commitAll(t, "feature")
+
+	// Changes on the base branch after the merge-base must not be reported.
+	gitCmd(t, "checkout", "--quiet", "main")
+	writeFile(t, "e/main.tf", "# e\n")
+	commitAll(t, "main")
+
+	paths, err := ChangedPaths("main", "feature")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join("a", "main.tf"),
+		filepath.Join("b", "main.tf"),
+		filepath.Join("c", "main.tf"),
+		filepath.Join("d", "main.tf"),
+	}, paths)
+
+	dirs, err := ChangedDirs("main", "feature")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, dirs)
+}
+
+func TestMergeBase(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/main.tf", "# a\n")
+	commitAll(t, "initial")
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	require.NoError(t, err)
+
+	gitCmd(t, "checkout", "--quiet", "-b", "feature")
+	writeFile(t, "a/main.tf", "# a changed\n")
+	commitAll(t, "feature")
+
+	gitCmd(t, "checkout", "--quiet", "main")
+	writeFile(t, "b/main.tf", "# b\n")
+	commitAll(t, "main moved ahead")
+
+	mergeBase, err := MergeBase("main", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), mergeBase)
+}
+
+func TestChangedPaths_NoChanges(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/main.tf", "# a\n")
+	commitAll(t, "initial")
+
+	paths, err := ChangedPaths("HEAD", "HEAD")
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+}
+
+func TestChangedPaths_UnknownRef(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/main.tf", "# a\n")
+	commitAll(t, "initial")
+
+	_, err := ChangedPaths("does-not-exist", "HEAD")
+	assert.Error(t, err)
+}
+
+func TestParseNameStatus(t *testing.T) {
+	out := []byte("M\x00a/main.tf\x00R087\x00b/old.tf\x00c/new.tf\x00D\x00a/main.tf\x00")
+
+	paths, err := parseNameStatus(out)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("a", "main.tf"),
+		filepath.Join("b", "old.tf"),
+		filepath.Join("c", "new.tf"),
+	}, paths)
+}
+
+func TestParseNameStatus_Truncated(t *testing.T) {
+	_, err := parseNameStatus([]byte("R100\x00b/old.tf\x00"))
+	assert.ErrorIs(t, err, ErrUnexpectedDiffOutput)
+}
+
+func TestListFilesAndReadFiles(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/pantalon.yaml", "name: a\n")
+	writeFile(t, "b/c/pantalon.yaml", "name: c\n")
+	writeFile(t, "b/main.tf", "# b\n")
+	commitAll(t, "initial")
+
+	// Changes to the working tree must not be visible at the ref.
+	require.NoError(t, os.RemoveAll("a"))
+	writeFile(t, "b/c/pantalon.yaml", "name: changed\n")
+
+	paths, err := ListFiles("HEAD", "pantalon.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("a", "pantalon.yaml"),
+		filepath.Join("b", "c", "pantalon.yaml"),
+	}, paths)
+
+	files, err := ReadFiles("HEAD", paths)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		filepath.Join("a", "pantalon.yaml"):      []byte("name: a\n"),
+		filepath.Join("b", "c", "pantalon.yaml"): []byte("name: c\n"),
+	}, files)
+}
+
+func TestListFiles_RelativeToWorkingDirectory(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/pantalon.yaml", "name: a\n")
+	writeFile(t, "b/c/pantalon.yaml", "name: c\n")
+	commitAll(t, "initial")
+	require.NoError(t, os.Chdir("b"))
+
+	paths, err := ListFiles("HEAD", "pantalon.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join("c", "pantalon.yaml")}, paths)
+
+	files, err := ReadFiles("HEAD", paths)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("name: c\n"), files[filepath.Join("c", "pantalon.yaml")])
+}
+
+func TestReadFiles_Missing(t *testing.T) {
+	newTestRepo(t)
+
+	writeFile(t, "a/pantalon.yaml", "name: a\n")
+	commitAll(t, "initial")
+
+	_, err := ReadFiles("HEAD", []string{filepath.Join("b", "pantalon.yaml")})
+	assert.ErrorIs(t, err, ErrFileNotFound)
+}
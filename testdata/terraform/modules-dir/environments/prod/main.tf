@@ -0,0 +1,13 @@
+# module "commented" { source = "../../modules/commented" }
+module "network" {
+  source = "../../modules/network"
+
+  tags = {
+    environment = "prod"
+  }
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}